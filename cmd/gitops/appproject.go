@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// AppProject is an ArgoCD AppProject declared in .gitops-config.yaml
+type AppProject struct {
+	Name string
+	// Repos are repository names on the git server, full URLs or "*"
+	Repos []string
+	// Destinations are namespaces on the local cluster, or "*"
+	Destinations []string
+	// ClusterResources are "<group>/<Kind>" entries allowed at cluster scope; the core group is empty
+	ClusterResources []string
+}
+
+// parseAppProject parses an app_project config entry:
+// "<name> repos=<repo>,... destinations=<namespace>,... [cluster_resources=<group>/<Kind>,...]"
+func parseAppProject(value string) (*AppProject, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("invalid app_project %q: expected \"<name> repos=... destinations=... [cluster_resources=...]\"", value)
+	}
+
+	project := &AppProject{Name: fields[0]}
+	for _, field := range fields[1:] {
+		key, list, found := strings.Cut(field, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid app_project %s setting %q: expected <key>=<value>,...", project.Name, field)
+		}
+		values := strings.Split(list, ",")
+		switch key {
+		case "repos":
+			project.Repos = values
+		case "destinations":
+			project.Destinations = values
+		case "cluster_resources":
+			project.ClusterResources = values
+		default:
+			return nil, fmt.Errorf("unknown app_project %s setting %q (use repos, destinations or cluster_resources)", project.Name, key)
+		}
+	}
+
+	if len(project.Repos) == 0 || len(project.Destinations) == 0 {
+		return nil, fmt.Errorf("app_project %s needs repos= and destinations=", project.Name)
+	}
+	return project, nil
+}
+
+// String formats the project as a config entry value
+func (p AppProject) String() string {
+	value := fmt.Sprintf("%s repos=%s destinations=%s", p.Name, strings.Join(p.Repos, ","), strings.Join(p.Destinations, ","))
+	if len(p.ClusterResources) > 0 {
+		value += " cluster_resources=" + strings.Join(p.ClusterResources, ",")
+	}
+	return value
+}
+
+// MarshalJSON writes the project in its config form
+func (p AppProject) MarshalJSON() ([]byte, error) {
+	return marshalJSON(p.String(), "")
+}
+
+// appProjectManifest renders the AppProject. Repository names are resolved to their
+// in-cluster URLs so they match the Applications' sources.
+func appProjectManifest(config *Config, project AppProject) string {
+	var spec strings.Builder
+	spec.WriteString("  sourceRepos:\n")
+	for _, repo := range project.Repos {
+		if repo != "*" && !strings.Contains(repo, "://") {
+			repo = gitRepoURL(config, repo)
+		}
+		fmt.Fprintf(&spec, "    - %q\n", repo)
+	}
+
+	spec.WriteString("  destinations:\n")
+	for _, namespace := range project.Destinations {
+		fmt.Fprintf(&spec, "    - server: https://kubernetes.default.svc\n      namespace: %q\n", namespace)
+	}
+
+	spec.WriteString("  clusterResourceWhitelist:")
+	if len(project.ClusterResources) == 0 {
+		spec.WriteString(" []\n")
+	} else {
+		spec.WriteString("\n")
+		for _, resource := range project.ClusterResources {
+			group, kind, _ := strings.Cut(resource, "/")
+			fmt.Fprintf(&spec, "    - group: %q\n      kind: %q\n", group, kind)
+		}
+	}
+
+	return fmt.Sprintf(`apiVersion: argoproj.io/v1alpha1
+kind: AppProject
+metadata:
+  name: %s
+  namespace: argocd
+  labels:
+    app.kubernetes.io/managed-by: gitops
+spec:
+  description: Declared in .gitops-config.yaml
+%s`, project.Name, spec.String())
+}
+
+// applyAppProjects creates or updates the AppProjects declared in the config
+func applyAppProjects(config *Config) error {
+	if len(config.AppProjects) == 0 {
+		return nil
+	}
+
+	var manifests []string
+	for _, project := range config.AppProjects {
+		manifests = append(manifests, appProjectManifest(config, project))
+	}
+
+	fmt.Fprintf(progress, "📋 Applying %d AppProject(s)...\n", len(config.AppProjects))
+	cmd := exec.Command("kubectl", "apply", "-f", "-")
+	cmd.Stdin = strings.NewReader(strings.Join(manifests, "---\n"))
+	if _, err := runCommand(cmd, "kubectl apply AppProjects"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// projectPaths holds the key path of the project an Application, or the
+// Applications an ApplicationSet generates, belong to
+var projectPaths = map[string]string{
+	"Application":    "spec.project",
+	"ApplicationSet": "spec.template.spec.project",
+}
+
+// bindApplicationProject rewrites the spec.project of every Application in a manifest,
+// and of ApplicationSet templates, to application_project. Other project keys, such as
+// in Helm values or other kinds, are kept. The manifest is unchanged when no project is
+// configured.
+func bindApplicationProject(config *Config, manifest string) string {
+	if config.ApplicationProject == "" {
+		return manifest
+	}
+
+	lines := strings.Split(manifest, "\n")
+	start := 0
+	for i := 0; i <= len(lines); i++ {
+		if i == len(lines) || strings.HasPrefix(lines[i], "---") {
+			bindDocumentProject(lines[start:i], config.ApplicationProject)
+			start = i + 1
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// bindDocumentProject rewrites the project key of one YAML document in place
+func bindDocumentProject(lines []string, project string) {
+	var target string
+	for _, line := range lines {
+		if value, found := strings.CutPrefix(line, "kind:"); found {
+			target = projectPaths[yamlScalarValue(value)]
+		}
+	}
+	if target == "" {
+		return
+	}
+
+	// parents holds the indentation and key of the mappings enclosing the current line
+	type parentKey struct {
+		indent int
+		key    string
+	}
+	var parents []parentKey
+	blockIndent := -1
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(trimmed)
+		// Skip the body of a block scalar, which is text rather than keys
+		if blockIndent >= 0 && indent > blockIndent {
+			continue
+		}
+		blockIndent = -1
+		for len(parents) > 0 && parents[len(parents)-1].indent >= indent {
+			parents = parents[:len(parents)-1]
+		}
+		key, value, found := strings.Cut(trimmed, ":")
+		if !found {
+			continue
+		}
+		path := key
+		for j := len(parents) - 1; j >= 0; j-- {
+			path = parents[j].key + "." + path
+		}
+		if path == target {
+			lines[i] = line[:indent] + "project: " + project
+		}
+		if value := yamlScalarValue(value); strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			blockIndent = indent
+		}
+		parents = append(parents, parentKey{indent, key})
+	}
+}
+
+// yamlScalarValue returns a plain or quoted scalar without its quotes and comment
+func yamlScalarValue(value string) string {
+	value, _, _ = strings.Cut(value, " #")
+	return strings.Trim(strings.TrimSpace(value), `"'`)
+}
+
+// applicationProject returns the project new Applications are bound to
+func applicationProject(config *Config) string {
+	if config.ApplicationProject == "" {
+		return "default"
+	}
+	return config.ApplicationProject
+}
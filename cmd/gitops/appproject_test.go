@@ -0,0 +1,122 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAppProject(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    AppProject
+		wantErr bool
+	}{
+		{
+			value: "team repos=app,api destinations=team-*",
+			want:  AppProject{Name: "team", Repos: []string{"app", "api"}, Destinations: []string{"team-*"}},
+		},
+		{
+			value: "platform repos=* destinations=* cluster_resources=/Namespace,rbac.authorization.k8s.io/ClusterRole",
+			want: AppProject{Name: "platform", Repos: []string{"*"}, Destinations: []string{"*"},
+				ClusterResources: []string{"/Namespace", "rbac.authorization.k8s.io/ClusterRole"}},
+		},
+		{value: "", wantErr: true},
+		{value: "team", wantErr: true},
+		{value: "team repos=app", wantErr: true},
+		{value: "team destinations=team", wantErr: true},
+		{value: "team repos=app destinations=team owners=me", wantErr: true},
+		{value: "team repos destinations=team", wantErr: true},
+	}
+	for _, tt := range tests {
+		project, err := parseAppProject(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseAppProject(%q) succeeded, want an error", tt.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseAppProject(%q): %v", tt.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(*project, tt.want) {
+			t.Errorf("parseAppProject(%q) = %+v, want %+v", tt.value, *project, tt.want)
+		}
+		// The config form parses back to the same project
+		if again, err := parseAppProject(project.String()); err != nil || !reflect.DeepEqual(again, project) {
+			t.Errorf("%q does not round-trip: %+v, %v", project.String(), again, err)
+		}
+	}
+}
+
+func TestBindApplicationProject(t *testing.T) {
+	manifest := `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: app
+  labels:
+    project: billing
+spec:
+  project: default
+  source:
+    helm:
+      values: |
+        project: keep-me
+        spec:
+          project: keep-me
+    repoURL: http://git-server.git-server.svc/app.git
+---
+apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+spec:
+  template:
+    spec:
+      project: "default" # generated
+---
+apiVersion: example.com/v1
+kind: Workspace
+spec:
+  project: billing
+`
+	want := `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: app
+  labels:
+    project: billing
+spec:
+  project: team
+  source:
+    helm:
+      values: |
+        project: keep-me
+        spec:
+          project: keep-me
+    repoURL: http://git-server.git-server.svc/app.git
+---
+apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+spec:
+  template:
+    spec:
+      project: team
+---
+apiVersion: example.com/v1
+kind: Workspace
+spec:
+  project: billing
+`
+	tests := []struct {
+		project string
+		want    string
+	}{
+		{"team", want},
+		{"", manifest},
+	}
+	for _, tt := range tests {
+		got := bindApplicationProject(&Config{ApplicationProject: tt.project}, manifest)
+		if got != tt.want {
+			t.Errorf("bindApplicationProject(%q) =\n%s\nwant\n%s", tt.project, got, tt.want)
+		}
+	}
+}
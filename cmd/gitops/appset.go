@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Settings of the ApplicationSet deploy generates with applicationset: true
+const (
+	applicationSetName               = "apps"
+	defaultApplicationSetDirectories = "apps/*"
+	// applicationSetWebhookURL is the in-cluster webhook of the ApplicationSet controller
+	applicationSetWebhookURL = "http://argocd-applicationset-controller.argocd.svc.cluster.local:7000/api/webhook"
+)
+
+// applicationSetManifest renders an ApplicationSet with a git directory generator over
+// the manifest repository. Each matching directory becomes an Application named after
+// it that deploys to a namespace of the same name.
+func applicationSetManifest(config *Config) string {
+	var directories strings.Builder
+	for _, dir := range strings.Split(config.ApplicationSetDirectories, ",") {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			fmt.Fprintf(&directories, "          - path: %q\n", dir)
+		}
+	}
+
+	repoURL := gitRepoURL(config, manifestRepository)
+	return fmt.Sprintf(`apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: %s
+  namespace: argocd
+spec:
+  goTemplate: true
+  goTemplateOptions: ["missingkey=error"]
+  generators:
+    - git:
+        repoURL: %s
+        revision: HEAD
+        requeueAfterSeconds: 30
+        directories:
+%s  template:
+    metadata:
+      name: '{{.path.basename}}'
+      labels:
+        app.kubernetes.io/managed-by: gitops
+    spec:
+      project: %s
+      source:
+        repoURL: %s
+        targetRevision: HEAD
+        path: '{{.path.path}}'
+      destination:
+        server: https://kubernetes.default.svc
+        namespace: '{{.path.basename}}'
+      syncPolicy:
+        automated:
+          prune: true
+          selfHeal: true
+        syncOptions:
+          - CreateNamespace=true
+`, applicationSetName, repoURL, directories.String(), applicationProject(config), repoURL)
+}
+
+// applyApplicationSet creates or updates the ApplicationSet over the project directories
+func applyApplicationSet(config *Config) error {
+	fmt.Fprintf(progress, "📋 Applying ApplicationSet %s over %s...\n", applicationSetName, config.ApplicationSetDirectories)
+
+	cmd := exec.Command("kubectl", "apply", "-f", "-")
+	cmd.Stdin = strings.NewReader(applicationSetManifest(config))
+	if _, err := runCommand(cmd, "kubectl apply ApplicationSet"); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(progress, "✅ ApplicationSet applied successfully")
+	return nil
+}
+
+// argoCDApplication is the part of an ArgoCD Application the CLI reports on
+type argoCDApplication struct {
+	Metadata struct {
+		Name            string `json:"name"`
+		OwnerReferences []struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+	Spec struct {
+		Project string `json:"project"`
+		Source  struct {
+			RepoURL string `json:"repoURL"`
+		} `json:"source"`
+	} `json:"spec"`
+	Status struct {
+		Sync struct {
+			Status   string `json:"status"`
+			Revision string `json:"revision"`
+		} `json:"sync"`
+		Health struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"health"`
+		Resources []struct {
+			Group     string `json:"group"`
+			Kind      string `json:"kind"`
+			Namespace string `json:"namespace"`
+			Name      string `json:"name"`
+			Status    string `json:"status"`
+			Health    struct {
+				Status  string `json:"status"`
+				Message string `json:"message"`
+			} `json:"health"`
+		} `json:"resources"`
+		Conditions []struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+		OperationState struct {
+			Phase      string `json:"phase"`
+			Message    string `json:"message"`
+			FinishedAt string `json:"finishedAt"`
+			SyncResult struct {
+				Resources []struct {
+					Group   string `json:"group"`
+					Kind    string `json:"kind"`
+					Name    string `json:"name"`
+					Status  string `json:"status"`
+					Message string `json:"message"`
+				} `json:"resources"`
+			} `json:"syncResult"`
+		} `json:"operationState"`
+	} `json:"status"`
+}
+
+// problems returns the Application's error conditions and the resources its last
+// sync rejected, such as resources an AppProject does not permit
+func (a argoCDApplication) problems() []string {
+	var problems []string
+	for _, condition := range a.Status.Conditions {
+		if strings.HasSuffix(condition.Type, "Error") || strings.HasSuffix(condition.Type, "Warning") {
+			problems = append(problems, fmt.Sprintf("%s: %s", condition.Type, condition.Message))
+		}
+	}
+	for _, resource := range a.Status.OperationState.SyncResult.Resources {
+		if resource.Status == "SyncFailed" {
+			problems = append(problems, fmt.Sprintf("%s/%s %s: %s", resource.Group, resource.Kind, resource.Name, resource.Message))
+		}
+	}
+	if len(problems) == 0 && (a.Status.OperationState.Phase == "Failed" || a.Status.OperationState.Phase == "Error") {
+		problems = append(problems, a.Status.OperationState.Message)
+	}
+	return problems
+}
+
+// applicationSet returns the name of the ApplicationSet that generated the Application, if any
+func (a argoCDApplication) applicationSet() string {
+	for _, owner := range a.Metadata.OwnerReferences {
+		if owner.Kind == "ApplicationSet" {
+			return owner.Name
+		}
+	}
+	return ""
+}
+
+// listArgoCDApplications returns all Applications in the argocd namespace
+func listArgoCDApplications() ([]argoCDApplication, error) {
+	cmd := exec.Command("kubectl", "get", "applications", "-n", argocdNamespace, "-o", "json")
+	output, err := runCommand(cmd, "kubectl get applications")
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Items []argoCDApplication `json:"items"`
+	}
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode applications: %w", err)
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Metadata.Name < list.Items[j].Metadata.Name })
+	return list.Items, nil
+}
+
+// printApplicationsByApplicationSet prints Applications grouped under the
+// ApplicationSet that generated them, followed by standalone Applications
+func printApplicationsByApplicationSet(apps []argoCDApplication) error {
+	groups := map[string][]argoCDApplication{}
+	var names []string
+	for _, app := range apps {
+		owner := app.applicationSet()
+		if _, ok := groups[owner]; !ok {
+			names = append(names, owner)
+		}
+		groups[owner] = append(groups[owner], app)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		// Standalone Applications ("") are listed last
+		if names[i] == "" || names[j] == "" {
+			return names[j] == ""
+		}
+		return names[i] < names[j]
+	})
+
+	w := tabwriter.NewWriter(progress, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "APPLICATIONSET\tNAME\tSYNC\tHEALTH")
+	for _, owner := range names {
+		group := owner
+		if group == "" {
+			group = "-"
+		}
+		for _, app := range groups[owner] {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", group, app.Metadata.Name, app.Status.Sync.Status, app.Status.Health.Status)
+		}
+	}
+	return w.Flush()
+}
+
+// waitForApplicationSet waits until the ApplicationSet generated its Applications and
+// they are synced, then prints them
+func waitForApplicationSet() error {
+	fmt.Fprintln(progress, "⏳ Waiting for generated applications to sync...")
+
+	var generated []argoCDApplication
+	deadline := time.Now().Add(90 * time.Second)
+	for {
+		apps, err := listArgoCDApplications()
+		if err != nil {
+			return err
+		}
+
+		generated = generated[:0]
+		synced := true
+		for _, app := range apps {
+			if app.applicationSet() == applicationSetName {
+				generated = append(generated, app)
+				synced = synced && app.Status.Sync.Status == "Synced"
+			}
+		}
+		if (len(generated) > 0 && synced) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(2 * time.Second)
+	}
+
+	if len(generated) == 0 {
+		fmt.Fprintln(progress, "⚠️  ApplicationSet generated no applications yet (may still be in progress)")
+		return nil
+	}
+	return printApplicationsByApplicationSet(generated)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var argocdCmd = &cobra.Command{
+	Use:   "argocd",
+	Short: "Manage the ArgoCD installation",
+}
+
+var argocdPasswordCmd = &cobra.Command{
+	Use:   "password",
+	Short: "Show or rotate the ArgoCD admin password",
+	Long:  "Prints the ArgoCD admin password stored for this project. With --rotate a new password is generated and applied to the cluster.",
+	RunE:  runArgoCDPassword,
+}
+
+var (
+	argocdTargetDir      string
+	argocdPasswordRotate bool
+)
+
+// argoCDPasswordKey is the secret store key of the ArgoCD admin password
+const argoCDPasswordKey = "argocd-admin-password"
+
+func init() {
+	argocdCmd.PersistentFlags().StringVar(&argocdTargetDir, "target-dir", ".", "Target directory containing .gitops-config.yaml")
+	argocdPasswordCmd.Flags().BoolVar(&argocdPasswordRotate, "rotate", false, "Generate and apply a new admin password")
+
+	argocdCmd.AddCommand(argocdPasswordCmd)
+}
+
+// argoCDAdminPassword returns the stored admin password, generating and storing one if needed
+func argoCDAdminPassword(store *secretStore) (string, error) {
+	password, err := store.get(argoCDPasswordKey)
+	if err != nil || password != "" {
+		return password, err
+	}
+
+	if password, err = generatePassword(20); err != nil {
+		return "", err
+	}
+	location, err := store.set(argoCDPasswordKey, password)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(progress, "🔑 Generated ArgoCD admin password (stored in %s)\n", location)
+	return password, nil
+}
+
+func runArgoCDPassword(cmd *cobra.Command, args []string) error {
+	config, err := readConfig(argocdTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	store, err := newSecretStore(config, argocdTargetDir)
+	if err != nil {
+		return err
+	}
+
+	if !argocdPasswordRotate {
+		password, err := store.get(argoCDPasswordKey)
+		if err != nil {
+			return err
+		}
+		if password == "" {
+			return fmt.Errorf("no ArgoCD password stored for cluster %s. Please run 'gitops setup' first", config.ClusterName)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), password)
+		return nil
+	}
+
+	if !argoCDHasServer(config) {
+		return fmt.Errorf("the %s ArgoCD install has no server and no admin password", config.ArgoCDInstall)
+	}
+	if err := setKubeconfig(config.ClusterName); err != nil {
+		return fmt.Errorf("failed to set kubeconfig: %w", err)
+	}
+
+	password, err := generatePassword(20)
+	if err != nil {
+		return err
+	}
+	if err := configureArgoCDPassword(password); err != nil {
+		return fmt.Errorf("failed to rotate ArgoCD password: %w", err)
+	}
+
+	// Only store the password once the cluster accepted it
+	location, err := store.set(argoCDPasswordKey, password)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(progress, "🔑 ArgoCD admin password rotated (stored in %s)\n", location)
+	fmt.Fprintln(cmd.OutOrStdout(), password)
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ArgoCD install variants selectable with argocd_install in .gitops-config.yaml
+const (
+	// argoCDInstallFull is the cluster-scoped install with UI, Dex and notifications
+	argoCDInstallFull = "full"
+	// argoCDInstallCore runs only the controllers, repo-server and redis: no UI, API or Dex
+	argoCDInstallCore = "core"
+	// argoCDInstallNamespace is the namespace-scoped install; ArgoCD may only manage argocd_namespaces
+	argoCDInstallNamespace = "namespace"
+)
+
+// defaultArgoCDNamespaces are the namespaces a namespace-scoped ArgoCD may deploy to
+const defaultArgoCDNamespaces = "argocd,default"
+
+// argoCDHasServer reports whether the install runs argocd-server (UI, API and webhooks)
+func argoCDHasServer(config *Config) bool {
+	return config.ArgoCDInstall != argoCDInstallCore
+}
+
+// argoCDInstallURLs returns the upstream manifests of the configured install variant
+func argoCDInstallURLs(config *Config) ([]string, error) {
+	base := fmt.Sprintf("https://raw.githubusercontent.com/argoproj/argo-cd/%s/manifests", config.ArgoCDVersion)
+	switch config.ArgoCDInstall {
+	case argoCDInstallFull:
+		return []string{base + "/install.yaml"}, nil
+	case argoCDInstallCore:
+		return []string{base + "/core-install.yaml"}, nil
+	case argoCDInstallNamespace:
+		// The namespace install leaves the cluster-scoped CRDs to the cluster admin
+		return []string{
+			base + "/crds/application-crd.yaml",
+			base + "/crds/applicationset-crd.yaml",
+			base + "/crds/appproject-crd.yaml",
+			base + "/namespace-install.yaml",
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown argocd_install %q (use full, core or namespace)", config.ArgoCDInstall)
+	}
+}
+
+// argoCDComponentWorkload returns the workload whose image tracks the ArgoCD version
+func argoCDComponentWorkload(config *Config) (string, string) {
+	if argoCDHasServer(config) {
+		return "deployment/argocd-server", "argocd-server"
+	}
+	return "deployment/argocd-repo-server", "argocd-repo-server"
+}
+
+// argoCDNamespaceAccessManifest lets a namespace-scoped ArgoCD deploy to the given
+// namespaces: an in-cluster cluster Secret limited to them plus admin RoleBindings
+func argoCDNamespaceAccessManifest(namespaces []string) string {
+	var manifest strings.Builder
+	fmt.Fprintf(&manifest, `apiVersion: v1
+kind: Secret
+metadata:
+  name: in-cluster
+  namespace: argocd
+  labels:
+    argocd.argoproj.io/secret-type: cluster
+    app.kubernetes.io/managed-by: gitops
+stringData:
+  name: in-cluster
+  server: https://kubernetes.default.svc
+  namespaces: %s
+`, strings.Join(namespaces, ","))
+
+	for _, namespace := range namespaces {
+		if namespace != argocdNamespace {
+			fmt.Fprintf(&manifest, `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: %s
+`, namespace)
+		}
+		fmt.Fprintf(&manifest, `---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: argocd-manage
+  namespace: %s
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: admin
+subjects:
+  - kind: ServiceAccount
+    name: argocd-application-controller
+    namespace: argocd
+  - kind: ServiceAccount
+    name: argocd-server
+    namespace: argocd
+`, namespace)
+	}
+	return manifest.String()
+}
+
+// applyArgoCDNamespaceAccess grants a namespace-scoped ArgoCD the configured namespaces
+func applyArgoCDNamespaceAccess(config *Config) error {
+	var namespaces []string
+	for _, namespace := range strings.Split(config.ArgoCDNamespaces, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	fmt.Fprintf(progress, "🔐 Granting ArgoCD access to namespaces: %s\n", strings.Join(namespaces, ", "))
+	cmd := exec.Command("kubectl", "apply", "-f", "-")
+	cmd.Stdin = strings.NewReader(argoCDNamespaceAccessManifest(namespaces))
+	_, err := runCommand(cmd, "kubectl apply ArgoCD namespace access")
+	return err
+}
+
+// refreshArgoCDApplications asks ArgoCD to refresh every Application sourced from a
+// repository. It is used where argocd-server, and thus its webhook, is not installed.
+func refreshArgoCDApplications(config *Config, repository string) error {
+	apps, err := listArgoCDApplications()
+	if err != nil {
+		return err
+	}
+
+	repoURL := gitRepoURL(config, repository)
+	for _, app := range apps {
+		if app.Spec.Source.RepoURL != repoURL {
+			continue
+		}
+		if err := refreshArgoCDApplication(app.Metadata.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshArgoCDApplication makes ArgoCD compare an Application with git right away
+func refreshArgoCDApplication(name string) error {
+	cmd := exec.Command("kubectl", "annotate", "application", name, "-n", argocdNamespace,
+		"--overwrite", "argocd.argoproj.io/refresh=normal")
+	_, err := runCommand(cmd, "kubectl annotate application "+name)
+	return err
+}
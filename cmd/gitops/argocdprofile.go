@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ArgoCD profiles selectable with argocd_profile in .gitops-config.yaml
+const (
+	argoCDProfileLocal   = "local"
+	argoCDProfileDefault = "default"
+)
+
+// Defaults of the local ArgoCD profile, each overridable in .gitops-config.yaml
+const (
+	defaultArgoCDReconciliationTimeout = "30s"
+	defaultArgoCDRepoCacheExpiration   = "1m"
+	defaultArgoCDCPURequest            = "10m"
+	defaultArgoCDMemoryRequest         = "64Mi"
+	defaultArgoCDResourceExclusions    = "coordination.k8s.io/Lease,discovery.k8s.io/EndpointSlice,/Endpoints"
+)
+
+// argoCDResourceExclusions renders a comma separated "<group>/<Kind>" list as the
+// resource.exclusions value of argocd-cm
+func argoCDResourceExclusions(list string) string {
+	var exclusions strings.Builder
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		group, kind, _ := strings.Cut(entry, "/")
+		fmt.Fprintf(&exclusions, "- apiGroups: [%q]\n  kinds: [%q]\n  clusters: [\"*\"]\n", group, kind)
+	}
+	return exclusions.String()
+}
+
+// patchConfigMap merges data into an ArgoCD ConfigMap
+func patchConfigMap(name string, data map[string]string) error {
+	patch, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("kubectl", "patch", "configmap", name, "-n", argocdNamespace, "--type", "merge", "--patch", string(patch))
+	_, err = runCommand(cmd, "kubectl patch configmap "+name)
+	return err
+}
+
+// waitForArgoCDRollout waits until every ArgoCD deployment and statefulset finished rolling out
+func waitForArgoCDRollout() error {
+	// rollout status needs named resources, so list them first
+	cmd := exec.Command("kubectl", "get", "deployment,statefulset", "-n", argocdNamespace, "-o", "name")
+	output, err := runCommand(cmd, "kubectl get ArgoCD workloads")
+	if err != nil {
+		return err
+	}
+	for _, workload := range strings.Fields(string(output)) {
+		cmd := exec.Command("kubectl", "rollout", "status", workload, "-n", argocdNamespace, "--timeout=300s")
+		if _, err := runCommand(cmd, "kubectl rollout status "+workload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyArgoCDProfile tunes an installed ArgoCD for a fast local feedback loop: short
+// reconciliation, a short repo-server cache, plain HTTP and small resource requests
+func applyArgoCDProfile(config *Config) error {
+	if config.ArgoCDProfile == argoCDProfileDefault {
+		return nil
+	}
+	if config.ArgoCDProfile != argoCDProfileLocal {
+		return fmt.Errorf("unknown argocd_profile %q (use local or default)", config.ArgoCDProfile)
+	}
+
+	fmt.Fprintln(progress, "⚙️  Applying local ArgoCD profile...")
+
+	if err := patchConfigMap("argocd-cm", map[string]string{
+		"timeout.reconciliation": config.ArgoCDReconciliationTimeout,
+		"resource.exclusions":    argoCDResourceExclusions(config.ArgoCDResourceExclusions),
+	}); err != nil {
+		return err
+	}
+
+	if err := patchConfigMap("argocd-cmd-params-cm", map[string]string{
+		"server.insecure":                  fmt.Sprint(config.ArgoCDInsecure),
+		"reposerver.repo.cache.expiration": config.ArgoCDRepoCacheExpiration,
+	}); err != nil {
+		return err
+	}
+
+	requests := fmt.Sprintf("cpu=%s,memory=%s", config.ArgoCDCPURequest, config.ArgoCDMemoryRequest)
+	cmd := exec.Command("kubectl", "set", "resources", "deployment,statefulset", "--all", "-n", argocdNamespace, "--requests="+requests)
+	if _, err := runCommand(cmd, "kubectl set resources ArgoCD"); err != nil {
+		return err
+	}
+
+	// Command parameters are only read at startup
+	cmd = exec.Command("kubectl", "rollout", "restart", "deployment,statefulset", "-n", argocdNamespace)
+	if _, err := runCommand(cmd, "kubectl rollout restart ArgoCD"); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(progress, "⏳ Waiting for ArgoCD to restart...")
+	if err := waitForArgoCDRollout(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(progress, "✅ Local ArgoCD profile applied")
+	return nil
+}
+
+// argoCDServerScheme returns the scheme argocd-server answers with
+func argoCDServerScheme(config *Config) string {
+	if config.ArgoCDProfile == argoCDProfileLocal && config.ArgoCDInsecure {
+		return "http"
+	}
+	return "https"
+}
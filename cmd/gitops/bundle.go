@@ -0,0 +1,412 @@
+package main
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Create offline bundles for air-gapped setup",
+	Long:  "Packages all component manifests and images into a tarball that 'gitops setup --bundle' can use without network access",
+}
+
+var bundleCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Save component manifests and images into a bundle tarball",
+	RunE:  runBundleCreate,
+}
+
+var (
+	bundleOutput    string
+	bundleTargetDir string
+)
+
+func init() {
+	bundleCreateCmd.Flags().StringVarP(&bundleOutput, "output", "o", "gitops-bundle.tar", "Path of the bundle tarball to create")
+	bundleCreateCmd.Flags().StringVar(&bundleTargetDir, "target-dir", ".", "Target directory containing .gitops-config.yaml")
+
+	bundleCmd.AddCommand(bundleCreateCmd)
+}
+
+// Files inside a bundle tarball
+const (
+	bundleIndexFile         = "bundle.json"
+	bundleClusterImagesFile = "images-cluster.tar"
+	bundleHostImagesFile    = "images-host.tar"
+)
+
+// bundleIndex describes the content of a bundle
+type bundleIndex struct {
+	CreatedAt     time.Time         `json:"createdAt"`
+	CLIVersion    string            `json:"cliVersion"`
+	Manifests     map[string]string `json:"manifests"`
+	ClusterImages []string          `json:"clusterImages"`
+	HostImages    []string          `json:"hostImages"`
+}
+
+// offlineBundle is an extracted bundle used by setup
+type offlineBundle struct {
+	dir   string
+	index bundleIndex
+}
+
+var (
+	manifestImagePattern = regexp.MustCompile(`(?m)^\s*-?\s*image:\s*["']?([^"'\s]+)`)
+	pullPolicyAlways     = regexp.MustCompile(`(?m)^(\s*)imagePullPolicy:\s*Always\s*$`)
+)
+
+// manifestImages returns the distinct images referenced by the manifests
+func manifestImages(manifests ...string) []string {
+	seen := map[string]bool{}
+	var images []string
+	for _, manifest := range manifests {
+		for _, m := range manifestImagePattern.FindAllStringSubmatch(manifest, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				images = append(images, m[1])
+			}
+		}
+	}
+	sort.Strings(images)
+	return images
+}
+
+// preferLocalImages stops pods from pulling images that were imported into the cluster
+func preferLocalImages(manifest string) string {
+	return pullPolicyAlways.ReplaceAllString(manifest, "${1}imagePullPolicy: IfNotPresent")
+}
+
+// k3dImages returns the k3d helper images of the installed k3d version and the k3s
+// image of the cluster: the configured one, or else the k3d default
+func k3dImages(config *Config) ([]string, error) {
+	clusterImage := newK3dClusterConfig(config).Image
+	output, err := runCommand(exec.Command("k3d", "version"), "k3d version")
+	if err != nil {
+		return nil, err
+	}
+
+	var images []string
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		switch fields[0] {
+		case "k3d":
+			tag := strings.TrimPrefix(fields[2], "v")
+			images = append(images, "ghcr.io/k3d-io/k3d-proxy:"+tag, "ghcr.io/k3d-io/k3d-tools:"+tag)
+		case "k3s":
+			if clusterImage == "" {
+				images = append(images, "rancher/k3s:"+strings.ReplaceAll(fields[2], "+", "-"))
+			}
+		}
+	}
+	if clusterImage != "" {
+		images = append(images, clusterImage)
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("could not determine k3d and k3s versions from: %s", strings.TrimSpace(string(output)))
+	}
+	return images, nil
+}
+
+func runBundleCreate(cmd *cobra.Command, args []string) error {
+	fmt.Fprintln(progress, "📦 Creating offline bundle...")
+
+	workDir, err := os.MkdirTemp("", "gitops-bundle-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	config, err := readConfig(bundleTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	// Vendor component manifests for the configured versions
+	rendered, err := renderSetupManifests(config, false)
+	if err != nil {
+		return err
+	}
+
+	manifests := map[string]string{
+		"argocd":      preferLocalImages(rendered.ArgoCD),
+		"chartmuseum": rendered.ChartMuseum,
+		"git-server":  rendered.GitServer,
+	}
+	index := bundleIndex{
+		CreatedAt:  time.Now().UTC(),
+		CLIVersion: version,
+		Manifests:  map[string]string{},
+	}
+	var contents []string
+	for component, manifest := range manifests {
+		name := filepath.Join("manifests", component+".yaml")
+		if err := os.MkdirAll(filepath.Join(workDir, "manifests"), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(workDir, name), []byte(manifest), 0644); err != nil {
+			return err
+		}
+		index.Manifests[component] = name
+		contents = append(contents, manifest)
+	}
+
+	// Images pulled by pods go into the cluster, tooling images into the host Docker
+	index.ClusterImages = manifestImages(contents...)
+	hostImages, err := k3dImages(config)
+	if err != nil {
+		return err
+	}
+	index.HostImages = append(hostImages, "registry:2", gitImage(config))
+
+	if err := saveImages(index.ClusterImages, filepath.Join(workDir, bundleClusterImagesFile)); err != nil {
+		return err
+	}
+	if err := saveImages(index.HostImages, filepath.Join(workDir, bundleHostImagesFile)); err != nil {
+		return err
+	}
+
+	indexContent, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(workDir, bundleIndexFile), indexContent, 0644); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(progress, "🗜️  Writing %s...\n", bundleOutput)
+	if err := writeTarball(workDir, bundleOutput); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	fmt.Fprintf(progress, "✅ Bundle created: %s (%d cluster images, %d host images)\n", bundleOutput, len(index.ClusterImages), len(index.HostImages))
+	return nil
+}
+
+// downloadManifest fetches a manifest over HTTP
+func downloadManifest(url string) (string, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	return string(content), nil
+}
+
+// saveImages pulls the images and saves them into a single docker archive
+func saveImages(images []string, path string) error {
+	for _, image := range images {
+		fmt.Fprintf(progress, "🐳 Pulling %s...\n", image)
+		if _, err := runCommand(exec.Command("docker", "pull", image), "docker pull "+image); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(progress, "💾 Saving %d images...\n", len(images))
+	saveArgs := append([]string{"save", "-o", path}, images...)
+	if _, err := runCommand(exec.Command("docker", saveArgs...), "docker save"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// writeTarball packs the files of a directory into an uncompressed tarball
+func writeTarball(dir, path string) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	tw := tar.NewWriter(out)
+	err = filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		name, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(name)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		in, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		_, err = io.Copy(tw, in)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// openBundle extracts a bundle tarball into a temporary directory
+func openBundle(path string) (*offlineBundle, error) {
+	fmt.Fprintf(progress, "📦 Extracting bundle %s...\n", path)
+
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer in.Close()
+
+	dir, err := os.MkdirTemp("", "gitops-bundle-")
+	if err != nil {
+		return nil, err
+	}
+	bundle := &offlineBundle{dir: dir}
+
+	tr := tar.NewReader(in)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			bundle.Close()
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, dir+string(os.PathSeparator)) {
+			bundle.Close()
+			return nil, fmt.Errorf("invalid path in bundle: %s", header.Name)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			bundle.Close()
+			return nil, err
+		}
+		if err := extractFile(tr, target); err != nil {
+			bundle.Close()
+			return nil, err
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, bundleIndexFile))
+	if err != nil {
+		bundle.Close()
+		return nil, fmt.Errorf("bundle has no %s: %w", bundleIndexFile, err)
+	}
+	if err := json.Unmarshal(content, &bundle.index); err != nil {
+		bundle.Close()
+		return nil, fmt.Errorf("invalid %s: %w", bundleIndexFile, err)
+	}
+
+	if verbose {
+		fmt.Fprintf(progress, "📋 Bundle created %s by gitops %s\n", bundle.index.CreatedAt.Format(time.RFC3339), bundle.index.CLIVersion)
+	}
+	return bundle, nil
+}
+
+func extractFile(r io.Reader, target string) error {
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Close removes the extracted bundle
+func (b *offlineBundle) Close() {
+	os.RemoveAll(b.dir)
+}
+
+// manifests returns the vendored component manifests. The Git server manifest is
+// rendered again for the target cluster, keeping the bundled image.
+func (b *offlineBundle) manifests(config *Config) (setupManifests, error) {
+	read := func(component string) (string, error) {
+		name, ok := b.index.Manifests[component]
+		if !ok {
+			return "", fmt.Errorf("bundle has no %s manifest", component)
+		}
+		content, err := os.ReadFile(filepath.Join(b.dir, name))
+		return string(content), err
+	}
+
+	var manifests setupManifests
+	var err error
+	if manifests.ArgoCD, err = read("argocd"); err != nil {
+		return setupManifests{}, err
+	}
+	if manifests.ChartMuseum, err = read("chartmuseum"); err != nil {
+		return setupManifests{}, err
+	}
+	gitServer, err := read("git-server")
+	if err != nil {
+		return setupManifests{}, err
+	}
+	if config.GitServerBackend == gitBackendHost {
+		return manifests, nil
+	}
+	repository, _ := splitImageRef(gitServerImage(config))
+	for _, image := range manifestImages(gitServer) {
+		if strings.HasPrefix(image, repository+":") || strings.HasPrefix(image, repository+"@") {
+			manifests.GitServer = renderGitServerManifest(config, image)
+			return manifests, nil
+		}
+	}
+	return setupManifests{}, fmt.Errorf("bundle has no %s image; create it with git_server_backend: %s", repository, config.GitServerBackend)
+}
+
+// loadHostImages loads the k3s, k3d and tooling images into the local Docker daemon
+func (b *offlineBundle) loadHostImages() error {
+	fmt.Fprintln(progress, "🐳 Loading host images from bundle...")
+	cmd := exec.Command("docker", "load", "-i", filepath.Join(b.dir, bundleHostImagesFile))
+	_, err := runCommand(cmd, "docker load")
+	return err
+}
+
+// importClusterImages imports the component images into the k3d cluster nodes
+func (b *offlineBundle) importClusterImages(clusterName string) error {
+	fmt.Fprintln(progress, "📥 Importing component images into the cluster...")
+	cmd := exec.Command("k3d", "image", "import", filepath.Join(b.dir, bundleClusterImagesFile), "-c", clusterName)
+	_, err := runCommand(cmd, "k3d image import")
+	return err
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the pull-through image cache",
+	Long: `Manages the persistent pull-through cache registries that mirror docker.io, quay.io and ghcr.io.
+The cache is created by 'gitops setup --cache' (or pull_through_cache: true in .gitops-config.yaml)
+and is kept by 'gitops cleanup', so later setups do not pull images from the internet again.`,
+}
+
+var cacheStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show cache registries and their disk usage",
+	RunE:  runCacheStatus,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cached images",
+	Long:  "Empties the cache registries. With --delete the cache registries and their volumes are removed entirely.",
+	RunE:  runCachePrune,
+}
+
+var (
+	cacheTargetDir   string
+	cachePruneDelete bool
+)
+
+func init() {
+	cacheCmd.PersistentFlags().StringVar(&cacheTargetDir, "target-dir", ".", "Target directory containing .gitops-config.yaml")
+	cachePruneCmd.Flags().BoolVar(&cachePruneDelete, "delete", false, "Delete the cache registries and volumes instead of emptying them")
+
+	cacheCmd.AddCommand(cacheStatusCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+}
+
+// cacheUpstream is a remote registry mirrored by a pull-through cache registry
+type cacheUpstream struct {
+	Name      string
+	Host      string
+	RemoteURL string
+}
+
+var cacheUpstreams = []cacheUpstream{
+	{Name: "docker", Host: "docker.io", RemoteURL: "https://registry-1.docker.io"},
+	{Name: "quay", Host: "quay.io", RemoteURL: "https://quay.io"},
+	{Name: "ghcr", Host: "ghcr.io", RemoteURL: "https://ghcr.io"},
+}
+
+// cacheRegistryName is the k3d registry name of the cache for an upstream
+func cacheRegistryName(upstream cacheUpstream) string {
+	return fmt.Sprintf("gitops-cache-%s.localhost", upstream.Name)
+}
+
+// cacheVolumeName is the Docker volume that keeps the cached blobs across cleanups
+func cacheVolumeName(upstream cacheUpstream) string {
+	return fmt.Sprintf("gitops-cache-%s", upstream.Name)
+}
+
+// cacheRegistryPort returns the port of the cache for the upstream at the given index
+func cacheRegistryPort(config *Config, index int) (string, error) {
+	base, err := strconv.Atoi(config.CachePort)
+	if err != nil {
+		return "", fmt.Errorf("invalid cache_port %q: %w", config.CachePort, err)
+	}
+	return strconv.Itoa(base + index), nil
+}
+
+// createPullThroughCache creates the cache registries that are missing
+func createPullThroughCache(config *Config) error {
+	fmt.Fprintln(progress, "🗄️  Creating pull-through image cache...")
+
+	inventory, err := loadK3dInventory()
+	if err != nil {
+		return err
+	}
+
+	for i, upstream := range cacheUpstreams {
+		name := cacheRegistryName(upstream)
+		if _, ok := inventory.registry(name); ok {
+			if verbose {
+				fmt.Fprintf(progress, "ℹ️  Cache registry %s already exists\n", name)
+			}
+			continue
+		}
+
+		port, err := cacheRegistryPort(config, i)
+		if err != nil {
+			return err
+		}
+
+		cmd := exec.Command("k3d", "registry", "create", name,
+			"--port", port,
+			"--proxy-remote-url", upstream.RemoteURL,
+			"-v", fmt.Sprintf("%s:/var/lib/registry", cacheVolumeName(upstream)))
+		if _, err := runCommand(cmd, "k3d registry create "+name); err != nil {
+			return err
+		}
+		fmt.Fprintf(progress, "✅ Cache for %s created at %s:%s\n", upstream.Host, name, port)
+	}
+
+	return nil
+}
+
+// addPullThroughCache connects the cache registries to the cluster and mirrors the
+// upstream registries through them
+func addPullThroughCache(config *Config, cluster *k3dClusterConfig) error {
+	registriesYAML := "mirrors:\n"
+	for i, upstream := range cacheUpstreams {
+		port, err := cacheRegistryPort(config, i)
+		if err != nil {
+			return err
+		}
+
+		endpoint := fmt.Sprintf("k3d-%s:%s", cacheRegistryName(upstream), port)
+		cluster.Registries.Use = append(cluster.Registries.Use, endpoint)
+		registriesYAML += fmt.Sprintf("  %q:\n    endpoint:\n      - http://%s\n", upstream.Host, endpoint)
+	}
+
+	cluster.Registries.Config = registriesYAML
+	return nil
+}
+
+func runCacheStatus(cmd *cobra.Command, args []string) error {
+	config, err := readConfig(cacheTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	fmt.Fprintln(progress, "🗄️  Pull-through cache:")
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "UPSTREAM\tREGISTRY\tPORT\tSTATE\tREPOSITORIES\tSIZE")
+	for i, upstream := range cacheUpstreams {
+		name := cacheRegistryName(upstream)
+		port, err := cacheRegistryPort(config, i)
+		if err != nil {
+			return err
+		}
+
+		state, repositories, size := "missing", "-", "-"
+		inspectCmd := exec.Command("docker", "inspect", "-f", "{{.State.Status}}", "k3d-"+name)
+		if output, err := runCommand(inspectCmd, "docker inspect "+name); err == nil {
+			state = strings.TrimSpace(string(output))
+		}
+
+		if state == "running" {
+			cache := &registryClient{baseURL: "http://localhost:" + port, client: newRegistryClient(config).client}
+			if catalog, err := cache.catalog(); err == nil {
+				repositories = strconv.Itoa(len(catalog))
+			}
+
+			duCmd := exec.Command("docker", "exec", "k3d-"+name, "du", "-sh", "/var/lib/registry")
+			if output, err := runCommand(duCmd, "cache du "+name); err == nil {
+				if fields := strings.Fields(string(output)); len(fields) > 0 {
+					size = fields[0]
+				}
+			}
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", upstream.Host, name, port, state, repositories, size)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !config.PullThroughCache {
+		fmt.Fprintln(progress, "")
+		fmt.Fprintln(progress, "ℹ️  The cache is not enabled for this project; set pull_through_cache: true or run 'gitops setup --cache'")
+	}
+	return nil
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) error {
+	for _, upstream := range cacheUpstreams {
+		name := cacheRegistryName(upstream)
+
+		if cachePruneDelete {
+			fmt.Fprintf(progress, "🗑️  Deleting cache registry %s...\n", name)
+			deleteCmd := exec.Command("k3d", "registry", "delete", name)
+			if _, err := runCommand(deleteCmd, "k3d registry delete "+name); err != nil && verbose {
+				fmt.Fprintf(progress, "ℹ️  %v\n", err)
+			}
+			volumeCmd := exec.Command("docker", "volume", "rm", cacheVolumeName(upstream))
+			if _, err := runCommand(volumeCmd, "docker volume rm "+cacheVolumeName(upstream)); err != nil && verbose {
+				fmt.Fprintf(progress, "ℹ️  %v\n", err)
+			}
+			continue
+		}
+
+		fmt.Fprintf(progress, "🧹 Emptying cache registry %s...\n", name)
+		pruneCmd := exec.Command("docker", "exec", "k3d-"+name, "sh", "-c", "rm -rf /var/lib/registry/docker")
+		if _, err := runCommand(pruneCmd, "prune "+name); err != nil {
+			fmt.Fprintf(progress, "⚠️  Skipping %s: %v\n", name, err)
+			continue
+		}
+
+		// Restart so the registry drops its in-memory blob descriptor cache
+		restartCmd := exec.Command("docker", "restart", "k3d-"+name)
+		if _, err := runCommand(restartCmd, "docker restart "+name); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(progress, "✅ Cache pruned")
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var chartCmd = &cobra.Command{
+	Use:   "chart",
+	Short: "Inspect Helm charts in ChartMuseum",
+}
+
+var chartListCmd = &cobra.Command{
+	Use:   "list [chart]",
+	Short: "List charts and their versions",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runChartList,
+}
+
+var chartTargetDir string
+
+func init() {
+	chartCmd.PersistentFlags().StringVar(&chartTargetDir, "target-dir", ".", "Target directory containing .gitops-config.yaml")
+
+	chartCmd.AddCommand(chartListCmd)
+}
+
+// chartVersion is one version of a chart stored in ChartMuseum
+type chartVersion struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	AppVersion string `json:"appVersion"`
+	Created    string `json:"created"`
+	Digest     string `json:"digest"`
+}
+
+// chartListing is the document chart list emits with --output json|yaml
+type chartListing struct {
+	Charts []chartVersion `json:"charts"`
+}
+
+func runChartList(cmd *cobra.Command, args []string) error {
+	config, err := readConfig(chartTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := setKubeconfig(config.ClusterName); err != nil {
+		return fmt.Errorf("failed to set kubeconfig: %w", err)
+	}
+
+	charts, err := listCharts()
+	if err != nil {
+		return fmt.Errorf("failed to list charts: %w", err)
+	}
+	if len(args) == 1 {
+		var matching []chartVersion
+		for _, chart := range charts {
+			if chart.Name == args[0] {
+				matching = append(matching, chart)
+			}
+		}
+		charts = matching
+	}
+
+	if structuredOutputEnabled() {
+		if charts == nil {
+			charts = []chartVersion{}
+		}
+		return writeOutput(cmd.OutOrStdout(), chartListing{Charts: charts})
+	}
+
+	if len(charts) == 0 {
+		fmt.Fprintln(progress, "ℹ️  No charts found in ChartMuseum")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHART\tVERSION\tAPP VERSION\tCREATED")
+	for _, chart := range charts {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", chart.Name, chart.Version, chart.AppVersion, chart.Created)
+	}
+	return w.Flush()
+}
+
+// listCharts reads every chart version from the ChartMuseum API through a temporary
+// port forward, sorted by chart name with the newest upload first
+func listCharts() ([]chartVersion, error) {
+	port, err := freeLocalPort()
+	if err != nil {
+		return nil, err
+	}
+	forward, err := startPortForward("chartmuseum", "svc/chartmuseum", port, "8080")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start port forward to ChartMuseum: %w", err)
+	}
+	defer forward.Process.Kill()
+
+	baseURL := "http://localhost:" + port
+	if err := waitForHTTP(baseURL+"/health", 10*time.Second); err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(baseURL + "/api/charts")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ChartMuseum returned %s", resp.Status)
+	}
+
+	var index map[string][]chartVersion
+	if err := json.NewDecoder(resp.Body).Decode(&index); err != nil {
+		return nil, fmt.Errorf("failed to decode chart index: %w", err)
+	}
+
+	var charts []chartVersion
+	for _, versions := range index {
+		charts = append(charts, versions...)
+	}
+	sort.SliceStable(charts, func(i, j int) bool {
+		if charts[i].Name != charts[j].Name {
+			return charts[i].Name < charts[j].Name
+		}
+		return charts[i].Created > charts[j].Created
+	})
+	return charts, nil
+}
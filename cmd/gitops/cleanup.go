@@ -24,7 +24,7 @@ func init() {
 }
 
 func runCleanup(cmd *cobra.Command, args []string) error {
-	fmt.Println("🧹 Cleaning up Local GitOps Environment...")
+	fmt.Fprintln(progress, "🧹 Cleaning up Local GitOps Environment...")
 
 	// Read config from target directory
 	targetClusterName, err := readConfigClusterName()
@@ -33,7 +33,7 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 	}
 
 	if verbose {
-		fmt.Printf("📋 Using cluster name: %s\n", targetClusterName)
+		fmt.Fprintf(progress, "📋 Using cluster name: %s\n", targetClusterName)
 	}
 
 	// Set kubeconfig
@@ -51,7 +51,7 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to delete registry: %w", err)
 	}
 
-	fmt.Println("✅ Cleanup completed successfully!")
+	fmt.Fprintln(progress, "✅ Cleanup completed successfully!")
 	return nil
 }
 
@@ -86,59 +86,57 @@ func readConfigClusterName() (string, error) {
 }
 
 func deleteCluster(clusterName string) error {
-	fmt.Printf("🗑️  Deleting k3d cluster: %s\n", clusterName)
+	fmt.Fprintf(progress, "🗑️  Deleting k3d cluster: %s\n", clusterName)
 
 	// Check if cluster exists
-	cmd := exec.Command("k3d", "cluster", "list")
-	output, err := runCommand(cmd, "k3d cluster list")
+	inventory, err := loadK3dInventory()
 	if err != nil {
 		return err
 	}
 
 	if verbose {
-		fmt.Printf("📋 Available clusters: %s\n", strings.TrimSpace(string(output)))
+		fmt.Fprintf(progress, "📋 Available clusters: %d\n", len(inventory.Clusters))
 	}
 
-	if !strings.Contains(string(output), clusterName) {
-		fmt.Printf("ℹ️  Cluster %s not found\n", clusterName)
+	if _, ok := inventory.cluster(clusterName); !ok {
+		fmt.Fprintf(progress, "ℹ️  Cluster %s not found\n", clusterName)
 		return nil
 	}
 
 	// Delete cluster
-	cmd = exec.Command("k3d", "cluster", "delete", clusterName)
+	cmd := exec.Command("k3d", "cluster", "delete", clusterName)
 	if _, err := runCommand(cmd, "k3d cluster delete"); err != nil {
 		return err
 	}
 
-	fmt.Printf("✅ Cluster %s deleted\n", clusterName)
+	fmt.Fprintf(progress, "✅ Cluster %s deleted\n", clusterName)
 	return nil
 }
 
 func deleteRegistry() error {
-	fmt.Printf("🗑️  Deleting Docker registry: %s\n", registryName)
+	fmt.Fprintf(progress, "🗑️  Deleting Docker registry: %s\n", registryName)
 
 	// Check if registry exists
-	cmd := exec.Command("k3d", "registry", "list")
-	output, err := runCommand(cmd, "k3d registry list")
+	inventory, err := loadK3dInventory()
 	if err != nil {
 		return err
 	}
 
 	if verbose {
-		fmt.Printf("📋 Available registries: %s\n", strings.TrimSpace(string(output)))
+		fmt.Fprintf(progress, "📋 Available registries: %d\n", len(inventory.Registries))
 	}
 
-	if !strings.Contains(string(output), registryName) {
-		fmt.Printf("ℹ️  Registry %s not found\n", registryName)
+	if _, ok := inventory.registry(registryName); !ok {
+		fmt.Fprintf(progress, "ℹ️  Registry %s not found\n", registryName)
 		return nil
 	}
 
 	// Delete registry
-	cmd = exec.Command("k3d", "registry", "delete", registryName)
+	cmd := exec.Command("k3d", "registry", "delete", registryName)
 	if _, err := runCommand(cmd, "k3d registry delete"); err != nil {
 		return err
 	}
 
-	fmt.Printf("✅ Registry %s deleted\n", registryName)
+	fmt.Fprintf(progress, "✅ Registry %s deleted\n", registryName)
 	return nil
 }
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// k3dConfigAPIVersion is the k3d config file schema; k3d 5.4 and later read it
+const k3dConfigAPIVersion = "k3d.io/v1alpha4"
+
+// defaultClusterPorts map the load balancer's HTTP and HTTPS ports to the host when
+// cluster_port is not set
+func defaultClusterPorts() []NodeFiltered {
+	return []NodeFiltered{
+		{Value: "8080:80", NodeFilters: []string{"loadbalancer"}},
+		{Value: "8443:443", NodeFilters: []string{"loadbalancer"}},
+	}
+}
+
+// NodeFiltered is a config value applied to some nodes of the cluster, written the
+// way the k3d CLI takes it: "<value>@<nodefilter>[;<nodefilter>...]"
+type NodeFiltered struct {
+	Value       string
+	NodeFilters []string
+}
+
+// parseNodeFiltered parses a node-filtered value, using defaultFilter when none is given
+func parseNodeFiltered(key, value, defaultFilter string) (NodeFiltered, error) {
+	// Split on the last "@" so values such as "--tls-san=user@host" stay intact
+	filtered := NodeFiltered{Value: value, NodeFilters: []string{defaultFilter}}
+	if i := strings.LastIndex(value, "@"); i >= 0 && isNodeFilter(value[i+1:]) {
+		filtered.Value = value[:i]
+		filtered.NodeFilters = strings.Split(value[i+1:], ";")
+	}
+	if filtered.Value == "" {
+		return NodeFiltered{}, fmt.Errorf("invalid %s %q: expected \"<value>[@<nodefilter>;...]\"", key, value)
+	}
+	return filtered, nil
+}
+
+// isNodeFilter reports whether s is a k3d node filter list such as "server:0;agent:*"
+func isNodeFilter(s string) bool {
+	for _, filter := range strings.Split(s, ";") {
+		role, _, _ := strings.Cut(filter, ":")
+		switch role {
+		case "all", "server", "servers", "agent", "agents", "loadbalancer":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the value as a config entry value
+func (f NodeFiltered) String() string {
+	return f.Value + "@" + strings.Join(f.NodeFilters, ";")
+}
+
+// MarshalJSON writes the value in its config form
+func (f NodeFiltered) MarshalJSON() ([]byte, error) {
+	return marshalJSON(f.String(), "")
+}
+
+// hostPort returns the host port of a "[<ip>:]<host port>:<container port>" mapping
+func (f NodeFiltered) hostPort() string {
+	parts := strings.Split(f.Value, ":")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[len(parts)-2]
+}
+
+// parseClusterNodeCount parses cluster_servers or cluster_agents
+func parseClusterNodeCount(key, value string, min int) (int, error) {
+	count, err := strconv.Atoi(value)
+	if err != nil || count < min {
+		return 0, fmt.Errorf("invalid %s %q: expected a number of at least %d", key, value, min)
+	}
+	return count, nil
+}
+
+// kubernetesVersionPattern matches the Kubernetes versions k3s publishes images for,
+// such as 1.29.4, v1.29.4 or v1.29.4+k3s1
+var kubernetesVersionPattern = regexp.MustCompile(`^v?(\d+\.\d+\.\d+)(?:[+-](k3s\d+))?$`)
+
+// parseKubernetesVersion validates kubernetes_version
+func parseKubernetesVersion(value string) (string, error) {
+	if !kubernetesVersionPattern.MatchString(value) {
+		return "", fmt.Errorf("invalid kubernetes_version %q: expected MAJOR.MINOR.PATCH such as 1.29.4 or v1.29.4+k3s1", value)
+	}
+	return value, nil
+}
+
+// k3sImage returns the k3s image of a Kubernetes version accepted by parseKubernetesVersion
+func k3sImage(version string) string {
+	match := kubernetesVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return ""
+	}
+	build := match[2]
+	if build == "" {
+		build = "k3s1"
+	}
+	return "rancher/k3s:v" + match[1] + "-" + build
+}
+
+// k3dClusterConfig is a k3d config file of kind Simple
+type k3dClusterConfig struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Servers    int               `json:"servers"`
+	Agents     int               `json:"agents"`
+	KubeAPI    *k3dKubeAPI       `json:"kubeAPI,omitempty"`
+	Image      string            `json:"image,omitempty"`
+	Volumes    []k3dVolumeConfig `json:"volumes,omitempty"`
+	Ports      []k3dPortConfig   `json:"ports,omitempty"`
+	Registries struct {
+		Use    []string `json:"use,omitempty"`
+		Config string   `json:"config,omitempty"`
+	} `json:"registries"`
+	Options struct {
+		K3s struct {
+			ExtraArgs  []k3dArgConfig   `json:"extraArgs,omitempty"`
+			NodeLabels []k3dLabelConfig `json:"nodeLabels,omitempty"`
+		} `json:"k3s"`
+	} `json:"options"`
+}
+
+type k3dKubeAPI struct {
+	HostIP   string `json:"hostIP,omitempty"`
+	HostPort string `json:"hostPort"`
+}
+
+type k3dVolumeConfig struct {
+	Volume      string   `json:"volume"`
+	NodeFilters []string `json:"nodeFilters"`
+}
+
+type k3dPortConfig struct {
+	Port        string   `json:"port"`
+	NodeFilters []string `json:"nodeFilters"`
+}
+
+type k3dArgConfig struct {
+	Arg         string   `json:"arg"`
+	NodeFilters []string `json:"nodeFilters"`
+}
+
+type k3dLabelConfig struct {
+	Label       string   `json:"label"`
+	NodeFilters []string `json:"nodeFilters"`
+}
+
+// newK3dClusterConfig renders the cluster shape of the configuration, connected to
+// the local registry
+func newK3dClusterConfig(config *Config) *k3dClusterConfig {
+	cluster := &k3dClusterConfig{
+		APIVersion: k3dConfigAPIVersion,
+		Kind:       "Simple",
+		Servers:    config.ClusterServers,
+		Agents:     config.ClusterAgents,
+		Image:      config.ClusterImage,
+	}
+	cluster.Metadata.Name = config.ClusterName
+	if cluster.Image == "" && config.KubernetesVersion != "" {
+		cluster.Image = k3sImage(config.KubernetesVersion)
+	}
+
+	if config.ClusterAPIPort != "" {
+		cluster.KubeAPI = &k3dKubeAPI{HostPort: config.ClusterAPIPort}
+		if host, port, err := net.SplitHostPort(config.ClusterAPIPort); err == nil {
+			cluster.KubeAPI = &k3dKubeAPI{HostIP: host, HostPort: port}
+		}
+	}
+
+	for _, port := range config.ClusterPorts {
+		cluster.Ports = append(cluster.Ports, k3dPortConfig{Port: port.Value, NodeFilters: port.NodeFilters})
+	}
+	for _, volume := range config.ClusterVolumes {
+		cluster.addVolume(volume.Value, volume.NodeFilters...)
+	}
+	for _, label := range config.ClusterNodeLabels {
+		cluster.Options.K3s.NodeLabels = append(cluster.Options.K3s.NodeLabels,
+			k3dLabelConfig{Label: label.Value, NodeFilters: label.NodeFilters})
+	}
+	// k3d has no taint option, k3s takes them as a node argument
+	for _, taint := range config.ClusterNodeTaints {
+		cluster.Options.K3s.ExtraArgs = append(cluster.Options.K3s.ExtraArgs,
+			k3dArgConfig{Arg: "--node-taint=" + taint.Value, NodeFilters: taint.NodeFilters})
+	}
+	for _, arg := range config.ClusterK3sArgs {
+		cluster.Options.K3s.ExtraArgs = append(cluster.Options.K3s.ExtraArgs,
+			k3dArgConfig{Arg: arg.Value, NodeFilters: arg.NodeFilters})
+	}
+
+	cluster.Registries.Use = []string{fmt.Sprintf("k3d-%s:%s", registryName, registryPort)}
+	return cluster
+}
+
+// shapeDrift lists how an existing cluster differs from the rendered config in what
+// k3d only applies at creation: the node counts and the k3s image
+func (c *k3dClusterConfig) shapeDrift(existing k3dCluster) []string {
+	var drift []string
+	if existing.ServersCount != c.Servers {
+		drift = append(drift, fmt.Sprintf("%d server(s) instead of %d", existing.ServersCount, c.Servers))
+	}
+	if existing.AgentsCount != c.Agents {
+		drift = append(drift, fmt.Sprintf("%d agent(s) instead of %d", existing.AgentsCount, c.Agents))
+	}
+	// Without an image the cluster runs k3d's default, whatever it was at creation
+	if c.Image != "" {
+		for _, node := range existing.Nodes {
+			if node.Role == "server" && node.Image != c.Image {
+				drift = append(drift, fmt.Sprintf("image %s instead of %s", node.Image, c.Image))
+				break
+			}
+		}
+	}
+	return drift
+}
+
+// addVolume mounts a "<host path>:<node path>" volume into the filtered nodes
+func (c *k3dClusterConfig) addVolume(volume string, nodeFilters ...string) {
+	c.Volumes = append(c.Volumes, k3dVolumeConfig{Volume: volume, NodeFilters: nodeFilters})
+}
+
+// writeK3dClusterConfig writes the k3d config file of a cluster and returns its path
+func writeK3dClusterConfig(cluster *k3dClusterConfig) (string, error) {
+	homeDir, err := gitopsHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := marshalJSON(cluster, "")
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	out.WriteString("# Generated by gitops setup from .gitops-config.yaml\n")
+	if err := jsonToYAML(&out, data); err != nil {
+		return "", err
+	}
+
+	configPath := filepath.Join(homeDir, fmt.Sprintf("k3d-%s.yaml", cluster.Metadata.Name))
+	if err := os.WriteFile(configPath, []byte(out.String()), 0644); err != nil {
+		return "", fmt.Errorf("failed to write k3d config: %w", err)
+	}
+	return configPath, nil
+}
+
+// addClusterOption adds a repeatable node-filtered cluster key of the config file
+func (c *Config) addClusterOption(key, value string) error {
+	// Without a node filter, ports go to the load balancer and k3s args to the
+	// servers as with the k3d CLI; mounts, labels and taints go to every node
+	defaultFilter := "all"
+	switch key {
+	case "cluster_port":
+		defaultFilter = "loadbalancer"
+	case "cluster_k3s_arg":
+		defaultFilter = "server:*"
+	}
+	option, err := parseNodeFiltered(key, value, defaultFilter)
+	if err != nil {
+		return err
+	}
+
+	switch key {
+	case "cluster_port":
+		c.ClusterPorts = append(c.ClusterPorts, option)
+	case "cluster_volume":
+		c.ClusterVolumes = append(c.ClusterVolumes, option)
+	case "cluster_node_label":
+		c.ClusterNodeLabels = append(c.ClusterNodeLabels, option)
+	case "cluster_node_taint":
+		c.ClusterNodeTaints = append(c.ClusterNodeTaints, option)
+	case "cluster_k3s_arg":
+		c.ClusterK3sArgs = append(c.ClusterK3sArgs, option)
+	}
+	return nil
+}
+
+// clusterHostPorts returns the host ports the cluster publishes: its port mappings
+// and, when set, the API port
+func clusterHostPorts(config *Config) []string {
+	var ports []string
+	for _, port := range config.ClusterPorts {
+		if hostPort := port.hostPort(); hostPort != "" {
+			ports = append(ports, hostPort)
+		}
+	}
+	if config.ClusterAPIPort != "" {
+		_, apiPort, err := net.SplitHostPort(config.ClusterAPIPort)
+		if err != nil {
+			apiPort = config.ClusterAPIPort
+		}
+		ports = append(ports, apiPort)
+	}
+	return ports
+}
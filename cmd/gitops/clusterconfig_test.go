@@ -0,0 +1,126 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNodeFiltered(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    NodeFiltered
+		wantErr bool
+	}{
+		{value: "8080:80", want: NodeFiltered{"8080:80", []string{"loadbalancer"}}},
+		{value: "8080:80@loadbalancer", want: NodeFiltered{"8080:80", []string{"loadbalancer"}}},
+		{value: "/data:/data@server:0;agent:*", want: NodeFiltered{"/data:/data", []string{"server:0", "agent:*"}}},
+		{value: "--tls-san=user@host", want: NodeFiltered{"--tls-san=user@host", []string{"loadbalancer"}}},
+		{value: "--tls-san=user@host@servers:*", want: NodeFiltered{"--tls-san=user@host", []string{"servers:*"}}},
+		{value: "@server:0", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseNodeFiltered("cluster_port", tt.value, "loadbalancer")
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseNodeFiltered(%q) succeeded, want an error", tt.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseNodeFiltered(%q): %v", tt.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseNodeFiltered(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+		// The config form parses back to the same value
+		if again, err := parseNodeFiltered("cluster_port", got.String(), "all"); err != nil || !reflect.DeepEqual(again, got) {
+			t.Errorf("%q does not round-trip: %+v, %v", got.String(), again, err)
+		}
+	}
+}
+
+func TestIsNodeFilter(t *testing.T) {
+	tests := map[string]bool{
+		"all":                 true,
+		"server:0":            true,
+		"servers:*":           true,
+		"agent:1;agent:2":     true,
+		"loadbalancer":        true,
+		"server:0;host":       false,
+		"host":                false,
+		"":                    false,
+		"example.com:443":     false,
+		"server:0;agents:0-1": true,
+	}
+	for filter, want := range tests {
+		if got := isNodeFilter(filter); got != want {
+			t.Errorf("isNodeFilter(%q) = %v, want %v", filter, got, want)
+		}
+	}
+}
+
+func TestKubernetesVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		image   string
+	}{
+		{"1.29.4", "rancher/k3s:v1.29.4-k3s1"},
+		{"v1.29.4", "rancher/k3s:v1.29.4-k3s1"},
+		{"v1.29.4+k3s2", "rancher/k3s:v1.29.4-k3s2"},
+		{"1.29.4-k3s1", "rancher/k3s:v1.29.4-k3s1"},
+		{"1.29", ""},
+		{"1", ""},
+		{"latest", ""},
+		{"1.29.4-rc1", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		_, err := parseKubernetesVersion(tt.version)
+		if (err == nil) != (tt.image != "") {
+			t.Errorf("parseKubernetesVersion(%q) error = %v", tt.version, err)
+		}
+		if got := k3sImage(tt.version); got != tt.image {
+			t.Errorf("k3sImage(%q) = %q, want %q", tt.version, got, tt.image)
+		}
+	}
+}
+
+func TestClusterHostPorts(t *testing.T) {
+	tests := []struct {
+		ports   []NodeFiltered
+		apiPort string
+		want    []string
+	}{
+		{defaultClusterPorts(), "", []string{"8080", "8443"}},
+		{[]NodeFiltered{{Value: "127.0.0.1:9090:80"}}, "6550", []string{"9090", "6550"}},
+		{[]NodeFiltered{{Value: "9090:80/tcp"}}, "0.0.0.0:6550", []string{"9090", "6550"}},
+		{[]NodeFiltered{{Value: "80"}}, "", nil},
+	}
+	for _, tt := range tests {
+		config := &Config{ClusterPorts: tt.ports, ClusterAPIPort: tt.apiPort}
+		if got := clusterHostPorts(config); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("clusterHostPorts(%v, %q) = %v, want %v", tt.ports, tt.apiPort, got, tt.want)
+		}
+	}
+}
+
+func TestClusterShapeDrift(t *testing.T) {
+	clusters := loadTestClusters(t)
+	tests := []struct {
+		config Config
+		drift  int
+	}{
+		{Config{ClusterName: "devcluster", ClusterServers: 1, ClusterAgents: 1}, 0},
+		{Config{ClusterName: "devcluster", ClusterServers: 1, ClusterAgents: 1, KubernetesVersion: "1.29.4"}, 0},
+		{Config{ClusterName: "devcluster", ClusterServers: 1, ClusterAgents: 1, KubernetesVersion: "1.30.0"}, 1},
+		{Config{ClusterName: "devcluster", ClusterServers: 3, ClusterAgents: 0}, 2},
+	}
+	for _, tt := range tests {
+		drift := newK3dClusterConfig(&tt.config).shapeDrift(clusters[0])
+		if len(drift) != tt.drift {
+			t.Errorf("shapeDrift(%+v) = %v, want %d difference(s)", tt.config, drift, tt.drift)
+		}
+	}
+}
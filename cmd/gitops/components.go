@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Component versions pinned by this CLI release. Each can be overridden in
+// .gitops-config.yaml (argocd_version, chartmuseum_version, git_server_version, git_image_version).
+const (
+	defaultArgoCDVersion      = "v2.13.3"
+	defaultChartMuseumVersion = "v0.16.2"
+	defaultGitServerVersion   = "1.0.0"
+	defaultGitImageVersion    = "v2.47.1"
+)
+
+// Image repositories of the installed components
+const (
+	argoCDImageRepository      = "quay.io/argoproj/argocd"
+	chartMuseumImageRepository = "chartmuseum/chartmuseum"
+	gitServerImageRepository   = "moikot/basic-git-server"
+	gitImageRepository         = "alpine/git"
+)
+
+// component is an installed workload whose image version is managed by the CLI
+type component struct {
+	Name      string
+	Namespace string
+	Workload  string
+	Container string
+	Image     string
+}
+
+// components returns the managed components with the images configured for them
+func components(config *Config) []component {
+	argoCDWorkload, argoCDContainer := argoCDComponentWorkload(config)
+	managed := []component{
+		{Name: "ArgoCD", Namespace: "argocd", Workload: argoCDWorkload, Container: argoCDContainer, Image: argoCDImageRepository + ":" + config.ArgoCDVersion},
+		{Name: "ChartMuseum", Namespace: "chartmuseum", Workload: "deployment/chartmuseum", Container: "chartmuseum", Image: chartMuseumImageRepository + ":" + config.ChartMuseumVersion},
+	}
+	if image := gitServerImage(config); image != "" {
+		managed = append(managed, component{Name: "Git server", Namespace: "git-server", Workload: "deployment/git-server", Container: "git-server", Image: image})
+	}
+	return managed
+}
+
+// gitImage returns the git client image used to push manifests
+func gitImage(config *Config) string {
+	return gitImageRepository + ":" + config.GitImageVersion
+}
+
+// setupManifests holds the YAML manifests applied by setup
+type setupManifests struct {
+	ArgoCD      string
+	ChartMuseum string
+	GitServer   string
+}
+
+// renderSetupManifests downloads the ArgoCD manifest for the configured version and
+// renders the other components. With pinDigests every image is resolved to its digest.
+func renderSetupManifests(config *Config, pinDigests bool) (setupManifests, error) {
+	fmt.Fprintf(progress, "📥 Downloading ArgoCD %s manifest (%s install)...\n", config.ArgoCDVersion, config.ArgoCDInstall)
+	urls, err := argoCDInstallURLs(config)
+	if err != nil {
+		return setupManifests{}, err
+	}
+	var parts []string
+	for _, url := range urls {
+		part, err := downloadManifest(url)
+		if err != nil {
+			return setupManifests{}, err
+		}
+		parts = append(parts, strings.TrimSpace(part))
+	}
+	argoCD := strings.Join(parts, "\n---\n") + "\n"
+
+	manifests := setupManifests{
+		ArgoCD:      argoCD,
+		ChartMuseum: chartMuseumManifest(chartMuseumImageRepository + ":" + config.ChartMuseumVersion),
+		GitServer:   renderGitServerManifest(config, gitServerImage(config)),
+	}
+	if !pinDigests {
+		return manifests, nil
+	}
+
+	fmt.Fprintln(progress, "📌 Resolving image digests...")
+	for _, manifest := range []*string{&manifests.ArgoCD, &manifests.ChartMuseum, &manifests.GitServer} {
+		if *manifest, err = pinImageDigests(*manifest); err != nil {
+			return setupManifests{}, err
+		}
+	}
+	return manifests, nil
+}
+
+// pinImageDigests rewrites every image reference in a manifest to "<image>@<digest>"
+func pinImageDigests(manifest string) (string, error) {
+	for _, image := range manifestImages(manifest) {
+		if strings.Contains(image, "@") {
+			continue
+		}
+
+		digest, err := resolveRemoteDigest(image)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve digest of %s: %w", image, err)
+		}
+		if verbose {
+			fmt.Fprintf(progress, "📌 %s -> %s\n", image, digest)
+		}
+
+		pattern := regexp.MustCompile(`(?m)^(\s*-?\s*image:\s*["']?)` + regexp.QuoteMeta(image) + `(["']?\s*)$`)
+		manifest = pattern.ReplaceAllString(manifest, "${1}"+image+"@"+digest+"${2}")
+	}
+	return manifest, nil
+}
+
+// deployedImage returns the image a component's container currently runs
+func deployedImage(c component) (string, error) {
+	cmd := exec.Command("kubectl", "get", c.Workload, "-n", c.Namespace,
+		"-o", fmt.Sprintf(`jsonpath={.spec.template.spec.containers[?(@.name=="%s")].image}`, c.Container))
+	output, err := runCommand(cmd, "kubectl get "+c.Workload)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the GitOps configuration",
+}
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Print the effective configuration",
+	Long: `Prints .gitops-config.yaml with the defaults of unset keys filled in. The text output
+is itself a config file; with --output json|yaml, repeated keys such as mirror and
+app_project are shown as lists of their config values.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigView,
+}
+
+var configTargetDir string
+
+func init() {
+	configViewCmd.Flags().StringVar(&configTargetDir, "target-dir", ".", "Target directory containing .gitops-config.yaml")
+
+	configCmd.AddCommand(configViewCmd)
+}
+
+func runConfigView(cmd *cobra.Command, args []string) error {
+	config, err := readConfig(configTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if structuredOutputEnabled() {
+		return writeOutput(cmd.OutOrStdout(), config)
+	}
+
+	// The text form is a config file, so it can be saved and read back as it is
+	content, err := formatConfigFile(config)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "# Effective configuration of %s\n%s", filepath.Join(configTargetDir, ".gitops-config.yaml"), content)
+	return nil
+}
+
+// formatConfigFile renders the configuration as .gitops-config.yaml lines, one per
+// entry of repeated keys
+func formatConfigFile(config *Config) (string, error) {
+	data, err := marshalJSON(config, "")
+	if err != nil {
+		return "", err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decodeOrdered(decoder, &value); err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	for _, field := range value.([]yamlField) {
+		entries, ok := field.value.([]interface{})
+		if !ok {
+			entries = []interface{}{field.value}
+		}
+		for _, entry := range entries {
+			if entry == nil {
+				continue
+			}
+			line := fmt.Sprintf("%s: %v", field.key, entry)
+			out.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+	}
+	return out.String(), nil
+}
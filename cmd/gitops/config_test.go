@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatConfigFileRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	configFile := `cluster_name: roundtrip
+argocd_namespaces:
+image_policy: myapp semver:>=1.0.0 <2.0.0
+image_policy: api regex:^main-[0-9a-f]+$
+image_policy: worker latest
+app_project: team repos=app,https://example.com/x.git destinations=team-* cluster_resources=/Namespace
+mirror: app /src/app main,develop
+cluster_agents: 2
+kubernetes_version: 1.29.4
+cluster_port: 9090:80@loadbalancer
+cluster_volume: /data:/data@server:0;agent:*
+cluster_k3s_arg: --tls-san=user@host
+`
+	if err := os.WriteFile(filepath.Join(dir, ".gitops-config.yaml"), []byte(configFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := readConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := formatConfigFile(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"cluster_name: roundtrip\n",
+		"argocd_namespaces:\n",
+		"image_policy: myapp semver:>=1.0.0 <2.0.0\n",
+		"app_project: team repos=app,https://example.com/x.git destinations=team-* cluster_resources=/Namespace\n",
+		"mirror: app /src/app main,develop\n",
+		"cluster_port: 9090:80@loadbalancer\n",
+		"cluster_volume: /data:/data@server:0;agent:*\n",
+		"cluster_k3s_arg: --tls-san=user@host@server:*\n",
+		"pin_digests: true\n",
+	} {
+		if !strings.Contains(first, line) {
+			t.Errorf("formatted config lacks %q:\n%s", line, first)
+		}
+	}
+
+	// Reading the formatted config back yields the same configuration
+	if err := os.WriteFile(filepath.Join(dir, ".gitops-config.yaml"), []byte(first), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reread, err := readConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := formatConfigFile(reread)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Errorf("config does not round-trip:\n%s\nbecame\n%s", first, second)
+	}
+}
+
+func TestReadConfigDefaults(t *testing.T) {
+	dir := t.TempDir()
+	missing, err := readConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".gitops-config.yaml"), []byte("# only comments\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	empty, err := readConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := defaultConfig()
+	want.ClusterPorts = defaultClusterPorts()
+	for name, config := range map[string]*Config{"missing file": missing, "empty file": empty} {
+		if !reflect.DeepEqual(config, want) {
+			t.Errorf("%s: readConfig = %+v, want the defaults %+v", name, config, want)
+		}
+	}
+
+	// cluster_port entries replace the default load balancer ports
+	if err := os.WriteFile(filepath.Join(dir, ".gitops-config.yaml"), []byte("cluster_port: 9090:80\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := readConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.ClusterPorts) != 1 || config.ClusterPorts[0].Value != "9090:80" {
+		t.Errorf("cluster ports = %v, want only 9090:80", config.ClusterPorts)
+	}
+}
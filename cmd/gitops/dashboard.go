@@ -0,0 +1,485 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// Dashboard layout limits
+const (
+	dashboardMaxEvents    = 8
+	dashboardEventWindow  = 30 * time.Minute
+	dashboardPollInterval = 5 * time.Second
+	dashboardLogTail      = "50"
+)
+
+// portForwardStatus reports whether a service's configured local port is in use
+type portForwardStatus struct {
+	Name    string
+	Address string
+	Active  bool
+}
+
+// dashboard is the state of the full-screen view of status --watch
+type dashboard struct {
+	config   *Config
+	apps     []applicationStatus
+	events   []warningEvent
+	forwards []portForwardStatus
+	selected int
+	message  string
+	updated  time.Time
+}
+
+// runDashboard shows Applications, their resources, recent warning events and port
+// forwards until q or Ctrl-C is pressed. Watches on Applications and events trigger
+// redraws; port forwards and event ages are refreshed on a timer.
+func runDashboard(config *Config) error {
+	if !isTerminal(os.Stdin) {
+		return fmt.Errorf("status --watch needs an interactive terminal")
+	}
+
+	restore, err := enterDashboardTerminal()
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	changed := make(chan struct{}, 1)
+	go watchResources(stop, changed, "applications", "-n", argocdNamespace)
+	go watchResources(stop, changed, "events", "--all-namespaces", "--field-selector", "type=Warning", "--watch-only")
+	keys := readKeys()
+
+	ticker := time.NewTicker(dashboardPollInterval)
+	defer ticker.Stop()
+
+	d := &dashboard{config: config}
+	d.refresh()
+	d.render()
+
+	// Bursts of watch updates are coalesced into one refresh
+	var refreshDue <-chan time.Time
+	for {
+		select {
+		case <-signals:
+			return nil
+		case <-changed:
+			if refreshDue == nil {
+				refreshDue = time.After(300 * time.Millisecond)
+			}
+			continue
+		case <-refreshDue:
+			refreshDue = nil
+			d.refresh()
+		case <-ticker.C:
+			d.refresh()
+		case key, ok := <-keys:
+			if !ok || key == "q" {
+				return nil
+			}
+			d.handleKey(key, keys)
+		}
+		d.render()
+	}
+}
+
+// refresh reloads Applications, warning events and port forwards. Errors are shown in
+// the message line and the previous state is kept.
+func (d *dashboard) refresh() {
+	apps, err := listArgoCDApplications()
+	if err != nil {
+		d.message = "⚠️  " + firstLine(err.Error())
+	} else {
+		d.apps = d.apps[:0]
+		for _, app := range apps {
+			d.apps = append(d.apps, newApplicationStatus(app))
+		}
+		sortApplicationStatus(d.apps)
+	}
+	if d.selected >= len(d.apps) {
+		d.selected = len(d.apps) - 1
+	}
+	if d.selected < 0 {
+		d.selected = 0
+	}
+
+	if events, err := listWarningEvents(dashboardEventWindow); err == nil {
+		d.events = events
+	}
+	d.forwards = collectPortForwardStatus(d.config)
+	d.updated = time.Now()
+}
+
+// selectedApp returns the highlighted Application, if any
+func (d *dashboard) selectedApp() (applicationStatus, bool) {
+	if d.selected < len(d.apps) {
+		return d.apps[d.selected], true
+	}
+	return applicationStatus{}, false
+}
+
+// handleKey runs the action bound to a key on the selected Application
+func (d *dashboard) handleKey(key string, keys <-chan string) {
+	switch key {
+	case "up", "k":
+		if d.selected > 0 {
+			d.selected--
+		}
+		return
+	case "down", "j":
+		if d.selected < len(d.apps)-1 {
+			d.selected++
+		}
+		return
+	}
+
+	app, ok := d.selectedApp()
+	if !ok {
+		return
+	}
+	switch key {
+	case "s":
+		d.message = "🔄 Sync requested for " + app.Name
+		if err := requestArgoCDSync(app.Name); err != nil {
+			d.message = "⚠️  Sync failed: " + firstLine(err.Error())
+		}
+	case "r":
+		d.message = "🔄 Refresh requested for " + app.Name
+		if err := refreshArgoCDApplication(app.Name); err != nil {
+			d.message = "⚠️  Refresh failed: " + firstLine(err.Error())
+		}
+	case "R":
+		d.message = restartWorkloads(app)
+	case "l":
+		showWorkloadLogs(app, keys)
+		// Logs were printed on the normal screen; switch back to the dashboard
+		fmt.Print("\x1b[?1049h\x1b[?25l")
+		d.message = ""
+	}
+	d.refresh()
+}
+
+// render draws the dashboard, cut to the terminal size
+func (d *dashboard) render() {
+	rows, cols := terminalSize()
+	var screen strings.Builder
+
+	fmt.Fprintf(&screen, "gitops status --watch · cluster %s · updated %s\n", d.config.ClusterName, d.updated.Format("15:04:05"))
+	screen.WriteString("↑/↓ select  s sync  r refresh  l logs  R restart  q quit\n\n")
+
+	w := tabwriter.NewWriter(&screen, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  APPLICATION\tPROJECT\tSYNC\tHEALTH\tREVISION\tLAST OPERATION")
+	if len(d.apps) == 0 {
+		fmt.Fprintln(w, "  (no applications)\t\t\t\t\t")
+	}
+	for i, app := range d.apps {
+		marker := " "
+		if i == d.selected {
+			marker = "▶"
+		}
+		name := app.Name
+		if app.ApplicationSet != "" {
+			name = app.ApplicationSet + "/" + app.Name
+		}
+		fmt.Fprintf(w, "%s %s\t%s\t%s\t%s\t%s\t%s\n", marker, name, app.Project, app.Sync, app.Health,
+			shortRevision(app.Revision), app.LastOperation)
+	}
+	w.Flush()
+
+	if app, ok := d.selectedApp(); ok {
+		fmt.Fprintf(&screen, "\nRESOURCES of %s\n", app.Name)
+		w = tabwriter.NewWriter(&screen, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  KIND\tNAMESPACE\tNAME\tSYNC\tHEALTH\tMESSAGE")
+		for _, resource := range app.Resources {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n", resource.Kind, resource.Namespace, resource.Name,
+				resource.Sync, resource.Health, resource.Message)
+		}
+		w.Flush()
+		for _, problem := range app.Problems {
+			fmt.Fprintf(&screen, "  ⚠️  %s\n", problem)
+		}
+	}
+
+	screen.WriteString("\nWARNING EVENTS\n")
+	w = tabwriter.NewWriter(&screen, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  AGE\tNAMESPACE\tOBJECT\tREASON\tMESSAGE")
+	for i, event := range d.events {
+		if i == dashboardMaxEvents {
+			break
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s/%s\t%s\t%s\n", formatAge(time.Since(event.LastSeen)), event.Namespace,
+			strings.ToLower(event.Kind), event.Name, event.Reason, event.Message)
+	}
+	w.Flush()
+
+	screen.WriteString("\nPORT FORWARDS\n")
+	w = tabwriter.NewWriter(&screen, 0, 0, 2, ' ', 0)
+	for _, forward := range d.forwards {
+		state := "not running (gitops port-forward)"
+		if forward.Active {
+			state = "active"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", forward.Name, forward.Address, state)
+	}
+	w.Flush()
+
+	if d.message != "" {
+		screen.WriteString("\n" + d.message + "\n")
+	}
+
+	// Cut every line to the terminal width and the screen to its height
+	lines := strings.Split(strings.TrimRight(screen.String(), "\n"), "\n")
+	if len(lines) > rows {
+		lines = lines[:rows]
+	}
+	for i, line := range lines {
+		if runes := []rune(line); len(runes) > cols {
+			lines[i] = string(runes[:cols])
+		}
+	}
+	fmt.Print("\x1b[H\x1b[2J" + strings.Join(lines, "\n"))
+}
+
+// watchResources runs a kubectl watch and signals changed for every update until stop
+// is closed. The watch is restarted when kubectl exits, for example on API timeouts.
+func watchResources(stop <-chan struct{}, changed chan<- struct{}, args ...string) {
+	for {
+		cmd := exec.Command("kubectl", append(append([]string{"get"}, args...), "--watch", "-o", "json")...)
+		stdout, err := cmd.StdoutPipe()
+		if err == nil {
+			err = cmd.Start()
+		}
+		if err == nil {
+			done := make(chan struct{})
+			go func() {
+				select {
+				case <-stop:
+					cmd.Process.Kill()
+				case <-done:
+				}
+			}()
+
+			decoder := json.NewDecoder(stdout)
+			for {
+				var object json.RawMessage
+				if decoder.Decode(&object) != nil {
+					break
+				}
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			}
+			cmd.Wait()
+			close(done)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// collectPortForwardStatus checks which of the configured forward ports are listening
+func collectPortForwardStatus(config *Config) []portForwardStatus {
+	var forwards []portForwardStatus
+	if argoCDHasServer(config) {
+		forwards = append(forwards, portForwardStatus{Name: "ArgoCD UI", Address: "localhost:" + config.ArgoCDPort})
+	}
+	forwards = append(forwards,
+		portForwardStatus{Name: "ChartMuseum", Address: "localhost:" + config.ChartMuseumPort},
+		portForwardStatus{Name: "Git server", Address: "localhost:" + config.GitServerPort},
+	)
+	for i := range forwards {
+		if conn, err := net.DialTimeout("tcp", forwards[i].Address, 200*time.Millisecond); err == nil {
+			conn.Close()
+			forwards[i].Active = true
+		}
+	}
+	return forwards
+}
+
+// workloadResources returns the resources of an Application that run pods
+func workloadResources(app applicationStatus) []resourceStatus {
+	var workloads []resourceStatus
+	for _, resource := range app.Resources {
+		switch resource.Kind {
+		case "apps/Deployment", "apps/StatefulSet", "apps/DaemonSet", "apps/ReplicaSet", "batch/Job", "batch/CronJob", "Pod":
+			workloads = append(workloads, resource)
+		}
+	}
+	return workloads
+}
+
+// kubectlRef returns the kind/name reference kubectl accepts for a resource
+func kubectlRef(resource resourceStatus) string {
+	return strings.ToLower(resource.kindName()) + "/" + resource.Name
+}
+
+// restartWorkloads restarts the deployments, statefulsets and daemonsets of an Application
+func restartWorkloads(app applicationStatus) string {
+	restarted := 0
+	for _, workload := range workloadResources(app) {
+		switch workload.Kind {
+		case "apps/Deployment", "apps/StatefulSet", "apps/DaemonSet":
+		default:
+			continue
+		}
+		cmd := exec.Command("kubectl", "rollout", "restart", kubectlRef(workload), "-n", workload.Namespace)
+		if _, err := runCommand(cmd, "kubectl rollout restart "+kubectlRef(workload)); err != nil {
+			return "⚠️  Restart failed: " + firstLine(err.Error())
+		}
+		restarted++
+	}
+	if restarted == 0 {
+		return "ℹ️  " + app.Name + " has no workloads to restart"
+	}
+	return fmt.Sprintf("🔁 Restarted %d workload(s) of %s", restarted, app.Name)
+}
+
+// showWorkloadLogs leaves the dashboard screen, prints the recent logs of the
+// Application's workloads and waits for a key
+func showWorkloadLogs(app applicationStatus, keys <-chan string) {
+	fmt.Print("\x1b[?25h\x1b[?1049l")
+	fmt.Printf("📜 Logs of %s (last %s lines per container)\n", app.Name, dashboardLogTail)
+
+	workloads := workloadResources(app)
+	if len(workloads) == 0 {
+		fmt.Println("ℹ️  No workloads found in the resource tree")
+	}
+	for _, workload := range workloads {
+		fmt.Printf("\n── %s -n %s\n", kubectlRef(workload), workload.Namespace)
+		// kubectl logs cannot resolve a CronJob, so select the pods of its Jobs
+		target := []string{kubectlRef(workload)}
+		if workload.Kind == "batch/CronJob" {
+			selector, err := workloadSelector(workload)
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+				continue
+			}
+			if selector == "" {
+				fmt.Println("ℹ️  No jobs have run yet")
+				continue
+			}
+			target = []string{"-l", selector}
+		}
+		args := append([]string{"logs"}, target...)
+		args = append(args, "-n", workload.Namespace, "--all-containers", "--prefix", "--tail", dashboardLogTail)
+		cmd := exec.Command("kubectl", args...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stdout
+		cmd.Run()
+	}
+
+	fmt.Print("\nPress any key to return to the dashboard")
+	<-keys
+}
+
+// enterDashboardTerminal switches the terminal to unbuffered silent input on the
+// alternate screen and drops progress messages, such as the --verbose command echo,
+// that would draw over the dashboard. The returned function restores both.
+func enterDashboardTerminal() (func(), error) {
+	saved, err := sttyCommand("-g").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terminal settings: %w", err)
+	}
+	if err := sttyCommand("-icanon", "-echo", "min", "1").Run(); err != nil {
+		return nil, fmt.Errorf("failed to configure terminal: %w", err)
+	}
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	savedProgress := progress
+	progress = io.Discard
+
+	return func() {
+		progress = savedProgress
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		sttyCommand(strings.TrimSpace(string(saved))).Run()
+	}, nil
+}
+
+// sttyCommand runs stty on the terminal attached to stdin
+func sttyCommand(args ...string) *exec.Cmd {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	return cmd
+}
+
+// terminalSize returns the rows and columns of the terminal, 24x80 when unknown
+func terminalSize() (int, int) {
+	output, err := sttyCommand("size").Output()
+	if err == nil {
+		if fields := strings.Fields(string(output)); len(fields) == 2 {
+			rows, rowsErr := strconv.Atoi(fields[0])
+			cols, colsErr := strconv.Atoi(fields[1])
+			if rowsErr == nil && colsErr == nil && rows > 0 && cols > 0 {
+				return rows, cols
+			}
+		}
+	}
+	return 24, 80
+}
+
+// readKeys reads key presses from stdin, reporting arrow keys as "up" and "down"
+func readKeys() <-chan string {
+	keys := make(chan string)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 16)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			input := string(buf[:n])
+			switch input {
+			case "\x1b[A":
+				keys <- "up"
+			case "\x1b[B":
+				keys <- "down"
+			default:
+				for _, r := range input {
+					keys <- string(r)
+				}
+			}
+		}
+	}()
+	return keys
+}
+
+// formatAge renders a duration the way kubectl shows ages: 45s, 12m, 3h, 2d
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// firstLine returns the first line of a possibly multi-line error message
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
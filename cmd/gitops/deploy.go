@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -29,7 +30,7 @@ func init() {
 }
 
 func runDeploy(cmd *cobra.Command, args []string) error {
-	fmt.Println("🚀 Deploying application...")
+	fmt.Fprintln(progress, "🚀 Deploying application...")
 
 	// Read configuration
 	config, err := readConfig(deployTargetDir)
@@ -38,7 +39,7 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 	}
 
 	if verbose {
-		fmt.Printf("📋 Using cluster name: %s\n", config.ClusterName)
+		fmt.Fprintf(progress, "📋 Using cluster name: %s\n", config.ClusterName)
 	}
 
 	// Check prerequisites
@@ -46,8 +47,18 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("prerequisites check failed: %w", err)
 	}
 
-	// Apply bootstrap.yaml to create ArgoCD application
-	if err := applyBootstrap(deployTargetDir); err != nil {
+	// Create the AppProjects the Applications are bound to
+	if err := applyAppProjects(config); err != nil {
+		return fmt.Errorf("failed to apply AppProjects: %w", err)
+	}
+
+	// Apply bootstrap.yaml to create ArgoCD application, or the ApplicationSet
+	// generating one Application per project directory
+	if config.ApplicationSet {
+		if err := applyApplicationSet(config); err != nil {
+			return fmt.Errorf("failed to apply ApplicationSet: %w", err)
+		}
+	} else if err := applyBootstrap(config, deployTargetDir); err != nil {
 		return fmt.Errorf("failed to apply bootstrap: %w", err)
 	}
 
@@ -56,15 +67,60 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to push manifest content: %w", err)
 	}
 
+	// Push the local repositories mirrored with 'gitops repo mirror --sync'
+	if err := syncRepoMirrors(config, deployTargetDir); err != nil {
+		return fmt.Errorf("failed to sync mirrors: %w", err)
+	}
+
 	// Sync ArgoCD application
-	if err := syncArgoCDApplication(); err != nil {
+	if config.ApplicationSet {
+		if err := waitForApplicationSet(); err != nil {
+			return fmt.Errorf("failed to check generated applications: %w", err)
+		}
+	} else if err := syncArgoCDApplication(); err != nil {
 		return fmt.Errorf("failed to sync ArgoCD application: %w", err)
 	}
 
-	fmt.Println("✅ Deployment completed successfully!")
+	fmt.Fprintln(progress, "✅ Deployment completed successfully!")
+
+	if structuredOutputEnabled() {
+		return writeDeployResult(cmd.OutOrStdout(), config)
+	}
 	return nil
 }
 
+// deployResult is the document deploy emits with --output json|yaml
+type deployResult struct {
+	Cluster      string              `json:"cluster"`
+	Mode         string              `json:"mode"`
+	Repository   string              `json:"repository"`
+	Mirrors      []string            `json:"mirrors"`
+	Applications []applicationStatus `json:"applications"`
+}
+
+// writeDeployResult reports what deploy applied and the resulting Application state
+func writeDeployResult(w io.Writer, config *Config) error {
+	result := deployResult{
+		Cluster:    config.ClusterName,
+		Mode:       "bootstrap",
+		Repository: gitRepoURL(config, manifestRepository),
+		Mirrors:    []string{},
+	}
+	if config.ApplicationSet {
+		result.Mode = "applicationset"
+	}
+	for _, mirror := range config.Mirrors {
+		result.Mirrors = append(result.Mirrors, mirror.Name)
+	}
+
+	apps, err := collectApplicationStatus(config, deployTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to get ArgoCD applications: %w", err)
+	}
+	result.Applications = append([]applicationStatus{}, apps...)
+	return writeOutput(w, result)
+}
+
 func checkDeployPrerequisites(clusterName string) error {
 	// Check if kubectl is available
 	if _, err := exec.LookPath("kubectl"); err != nil {
@@ -72,27 +128,30 @@ func checkDeployPrerequisites(clusterName string) error {
 	}
 
 	// Check if k3d cluster is running
-	cmd := exec.Command("k3d", "cluster", "list")
-	output, err := cmd.Output()
+	inventory, err := loadK3dInventory()
 	if err != nil {
 		return fmt.Errorf("failed to list clusters: %w", err)
 	}
 
-	if !strings.Contains(string(output), clusterName) {
-		return fmt.Errorf("cluster %s is not running. Please run 'gitops setup' first", clusterName)
+	cluster, ok := inventory.cluster(clusterName)
+	if !ok {
+		return fmt.Errorf("cluster %s does not exist. Please run 'gitops setup' first", clusterName)
+	}
+	if !cluster.running() {
+		return fmt.Errorf("cluster %s is stopped. Start it with 'k3d cluster start %s'", clusterName, clusterName)
 	}
 
 	// Set kubeconfig
-	cmd = exec.Command("k3d", "kubeconfig", "write", clusterName)
-	output, err = cmd.Output()
+	cmd := exec.Command("k3d", "kubeconfig", "write", clusterName)
+	output, err := cmd.Output()
 	if err != nil {
 		return fmt.Errorf("failed to get kubeconfig: %w", err)
 	}
 
 	os.Setenv("KUBECONFIG", strings.TrimSpace(string(output)))
 
-	// Check if ArgoCD is running
-	cmd = exec.Command("kubectl", "get", "deployment", "argocd-server", "-n", argocdNamespace)
+	// Check if ArgoCD is running; the application controller is part of every install variant
+	cmd = exec.Command("kubectl", "get", "statefulset", "argocd-application-controller", "-n", argocdNamespace)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("ArgoCD is not running. Please run 'gitops setup' first")
 	}
@@ -100,122 +159,141 @@ func checkDeployPrerequisites(clusterName string) error {
 	return nil
 }
 
-func applyBootstrap(targetDir string) error {
-	fmt.Println("📋 Applying bootstrap.yaml...")
+func applyBootstrap(config *Config, targetDir string) error {
+	fmt.Fprintln(progress, "📋 Applying bootstrap.yaml...")
 
 	// Check if bootstrap.yaml exists in target directory
 	bootstrapPath := filepath.Join(targetDir, "bootstrap.yaml")
-	if _, err := os.Stat(bootstrapPath); err != nil {
+	bootstrap, err := os.ReadFile(bootstrapPath)
+	if err != nil {
 		return fmt.Errorf("bootstrap.yaml not found in %s: %w", targetDir, err)
 	}
 
-	// Apply bootstrap.yaml
-	cmd := exec.Command("kubectl", "apply", "-f", bootstrapPath)
+	// Apply bootstrap.yaml, bound to the configured AppProject
+	cmd := exec.Command("kubectl", "apply", "-f", "-")
+	cmd.Stdin = strings.NewReader(bindApplicationProject(config, string(bootstrap)))
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to apply bootstrap.yaml: %w", err)
 	}
 
-	fmt.Println("✅ Bootstrap.yaml applied successfully")
+	fmt.Fprintln(progress, "✅ Bootstrap.yaml applied successfully")
 	return nil
 }
 
+// gitCheckoutMaster checks out master in a fresh clone, or starts it when the
+// repository is still empty
+const gitCheckoutMaster = `if git rev-parse -q --verify origin/master >/dev/null; then
+  git checkout -q -B master origin/master
+else
+  git symbolic-ref HEAD refs/heads/master
+fi`
+
 func pushManifestContent(config *Config, targetDir string) error {
-	fmt.Println("📤 Pushing manifest content to Git repository...")
+	fmt.Fprintln(progress, "📤 Pushing manifest content to Git repository...")
 
-	// Start port forward for git server
-	fmt.Println("🌐 Starting Git server port forward...")
-	portForwardCmd := exec.Command("kubectl", "port-forward", "-n", "git-server", "svc/git-server", fmt.Sprintf("%s:80", config.GitServerPort))
-	portForwardCmd.Stdout = nil
-	portForwardCmd.Stderr = nil
-	if err := portForwardCmd.Start(); err != nil {
-		return fmt.Errorf("failed to start port forward: %w", err)
+	// Make the git server reachable and ensure the repository exists
+	backend, err := newGitBackend(config, targetDir)
+	if err != nil {
+		return err
 	}
-	defer func() {
-		if portForwardCmd.Process != nil {
-			portForwardCmd.Process.Kill()
-		}
-	}()
+	disconnect, err := backend.Connect()
+	if err != nil {
+		return err
+	}
+	defer disconnect()
 
-	// Wait for port forward to establish
-	fmt.Println("⏳ Waiting for port forward to establish...")
-	time.Sleep(3 * time.Second)
+	if err := backend.CreateRepo(manifestRepository); err != nil {
+		return fmt.Errorf("failed to create repository %s: %w", manifestRepository, err)
+	}
 
-	// Test if port forward is working
-	fmt.Println("🔍 Testing port forward connection...")
-	testCmd := exec.Command("curl", "-s", "-o", "/dev/null", "-w", "%{http_code}", fmt.Sprintf("http://localhost:%s/manifest.git/info/refs?service=git-upload-pack", config.GitServerPort))
-	output, err := testCmd.Output()
-	if err != nil || strings.TrimSpace(string(output)) != "200" {
-		return fmt.Errorf("port forward test failed: %w", err)
+	sourceDir, err := filepath.Abs(targetDir)
+	if err != nil {
+		return err
 	}
-	fmt.Println("✅ Port forward is working")
 
-	// Use Docker container to copy manifest folder content, init git repo, and push
-	fmt.Println("🐳 Using Docker container to copy manifest folder, init, and push...")
+	// Commit the manifest folder on top of the repository history and push it. The
+	// first deploy clones the empty repository and creates the initial commit.
+	fmt.Fprintln(progress, "🐳 Using Docker container to commit and push the manifest folder...")
 	pushScript := fmt.Sprintf(`
-set -e  # Exit on any error
-echo "=== DOCKER CONTAINER DEBUG ==="
-echo "Source directory contents:"
-ls -la /source/
-echo "Manifest directory contents:"
-ls -la /source/manifest/
-echo "Creating workspace in container /tmp..."
-mkdir -p /tmp/workspace
-echo "Copying manifest folder content to container /tmp/workspace..."
-cp -r /source/manifest/* /tmp/workspace/
-cd /tmp/workspace
-echo "Files in workspace after copy:"
-ls -la
-echo "Initializing git repository..."
-git init
+set -e
 git config --global user.email 'gitops@example.com'
 git config --global user.name 'GitOps CLI'
-echo "Adding remote origin..."
-git remote add origin http://host.docker.internal:%s/manifest.git
-echo "Adding and committing files..."
-git add .
-echo "Git status after add:"
-git status
-git commit -m "Initial commit: nginx application manifests"
-echo "Pushing to remote repository..."
-git push -u origin master --force
-echo "=== END DOCKER CONTAINER DEBUG ==="
-`, config.GitServerPort)
-
-	dockerCmd := exec.Command("docker", "run", "--rm", "--entrypoint=",
-		"-v", fmt.Sprintf("%s:/source", targetDir),
-		"alpine/git:latest", "/bin/sh", "-c", pushScript)
+git clone %s /tmp/workspace
+cd /tmp/workspace
+%s
+find . -mindepth 1 -maxdepth 1 ! -name .git -exec rm -rf {} +
+cp -r /source/manifest/. /tmp/workspace/
+git add -A
+if git diff --cached --quiet; then
+  echo "Manifests are unchanged"
+else
+  git commit -m "Deploy manifests"
+fi
+git push origin master
+`, shellQuote(backend.PushURL(manifestRepository)), gitCheckoutMaster)
+
+	if err := runGitContainer(config, backend, []string{fmt.Sprintf("%s:/source:ro", sourceDir)}, pushScript); err != nil {
+		return fmt.Errorf("failed to push manifest content: %w", err)
+	}
 
-	if verbose {
-		dockerCmd.Stdout = os.Stdout
-		dockerCmd.Stderr = os.Stderr
+	fmt.Fprintln(progress, "✅ Manifest content pushed successfully")
+	notifyArgoCD(config, manifestRepository, "master")
+	return nil
+}
+
+// startGitServerPortForward forwards the in-cluster git server to its configured local
+// port and waits until checkPath answers. The returned function stops the forward.
+func startGitServerPortForward(config *Config, checkPath string) (func(), error) {
+	fmt.Fprintln(progress, "🌐 Starting Git server port forward...")
+	portForwardCmd := exec.Command("kubectl", "port-forward", "-n", "git-server", "svc/git-server", fmt.Sprintf("%s:80", config.GitServerPort))
+	portForwardCmd.Stdout = nil
+	portForwardCmd.Stderr = nil
+	if err := portForwardCmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start port forward: %w", err)
+	}
+	stop := func() {
+		if portForwardCmd.Process != nil {
+			portForwardCmd.Process.Kill()
+		}
 	}
 
-	if err := dockerCmd.Run(); err != nil {
-		return fmt.Errorf("failed to push manifest content: %w", err)
+	// Wait for port forward to establish
+	fmt.Fprintln(progress, "⏳ Waiting for port forward to establish...")
+	if err := waitForHTTP(fmt.Sprintf("http://localhost:%s%s", config.GitServerPort, checkPath), 15*time.Second); err != nil {
+		stop()
+		return nil, fmt.Errorf("port forward test failed: %w", err)
 	}
+	fmt.Fprintln(progress, "✅ Port forward is working")
 
-	fmt.Println("✅ Manifest content pushed successfully")
-	return nil
+	return stop, nil
 }
 
 func syncArgoCDApplication() error {
-	fmt.Println("🔄 Syncing ArgoCD application...")
+	fmt.Fprintln(progress, "🔄 Syncing ArgoCD application...")
 
-	// Wait a moment for the git push to be available
-	time.Sleep(2 * time.Second)
-
-	// Trigger ArgoCD application sync
-	cmd := exec.Command("kubectl", "patch", "application", appName, "-n", argocdNamespace, "--type", "merge", "--patch", `{"operation":{"sync":{}}}`)
-	if err := cmd.Run(); err != nil {
+	// Trigger ArgoCD application sync; the target revision is resolved when the sync starts
+	if err := requestArgoCDSync(appName); err != nil {
 		return fmt.Errorf("failed to trigger ArgoCD sync: %w", err)
 	}
 
-	// Wait for sync to complete
-	fmt.Println("⏳ Waiting for ArgoCD sync to complete...")
-	time.Sleep(5 * time.Second)
+	// Wait for the sync operation to finish instead of a fixed delay
+	fmt.Fprintln(progress, "⏳ Waiting for ArgoCD sync to complete...")
+	deadline := time.Now().Add(60 * time.Second)
+	for time.Now().Before(deadline) {
+		cmd := exec.Command("kubectl", "get", "application", appName, "-n", argocdNamespace, "-o", "jsonpath={.operation.sync}|{.status.operationState.phase}")
+		output, err := cmd.Output()
+		if err != nil {
+			return fmt.Errorf("failed to check sync operation: %w", err)
+		}
+		pending, phase, _ := strings.Cut(strings.TrimSpace(string(output)), "|")
+		if pending == "" && phase != "" && phase != "Running" {
+			break
+		}
+		time.Sleep(time.Second)
+	}
 
 	// Check sync status
-	cmd = exec.Command("kubectl", "get", "application", appName, "-n", argocdNamespace, "-o", "jsonpath={.status.sync.status}")
+	cmd := exec.Command("kubectl", "get", "application", appName, "-n", argocdNamespace, "-o", "jsonpath={.status.sync.status}")
 	output, err := cmd.Output()
 	if err != nil {
 		return fmt.Errorf("failed to check sync status: %w", err)
@@ -223,14 +301,21 @@ func syncArgoCDApplication() error {
 
 	syncStatus := strings.TrimSpace(string(output))
 	if verbose {
-		fmt.Printf("📋 ArgoCD sync status: %s\n", syncStatus)
+		fmt.Fprintf(progress, "📋 ArgoCD sync status: %s\n", syncStatus)
 	}
 
 	if syncStatus == "Synced" {
-		fmt.Println("✅ ArgoCD application synced successfully")
+		fmt.Fprintln(progress, "✅ ArgoCD application synced successfully")
 	} else {
-		fmt.Printf("⚠️  ArgoCD application sync status: %s (may still be in progress)\n", syncStatus)
+		fmt.Fprintf(progress, "⚠️  ArgoCD application sync status: %s (may still be in progress)\n", syncStatus)
 	}
 
 	return nil
 }
+
+// requestArgoCDSync starts a sync operation of an Application
+func requestArgoCDSync(name string) error {
+	cmd := exec.Command("kubectl", "patch", "application", name, "-n", argocdNamespace, "--type", "merge", "--patch", `{"operation":{"sync":{}}}`)
+	_, err := runCommand(cmd, "kubectl patch application "+name)
+	return err
+}
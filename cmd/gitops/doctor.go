@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the local environment",
+	Long: `Runs a catalogue of checks on the tools, Docker resources, ports and name resolution
+the local GitOps environment depends on. Each check passes, warns or fails, and problems
+come with a concrete fix. setup runs the critical checks before creating anything.`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+var doctorTargetDir string
+
+func init() {
+	doctorCmd.Flags().StringVar(&doctorTargetDir, "target-dir", ".", "Target directory containing .gitops-config.yaml")
+}
+
+// Results of a doctor check
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// Docker memory thresholds for a cluster running ArgoCD, ChartMuseum and a git server
+const (
+	dockerMemoryRecommended = 4 << 30
+	dockerMemoryMinimum     = 2 << 30
+)
+
+// diagnosis is the outcome of a doctor check
+type diagnosis struct {
+	Result string
+	Detail string
+	// Fix tells how to resolve a warning or failure
+	Fix string
+}
+
+// doctorCheck is one entry of the doctor catalogue
+type doctorCheck struct {
+	Name string
+	// Critical checks run before setup; a failure stops it
+	Critical bool
+	Run      func(config *Config) diagnosis
+}
+
+// doctorChecks is the catalogue run by gitops doctor, in order
+var doctorChecks = []doctorCheck{
+	{Name: "Docker", Critical: true, Run: toolCheck("docker")},
+	{Name: "k3d", Critical: true, Run: toolCheck("k3d")},
+	{Name: "kubectl", Critical: true, Run: toolCheck("kubectl")},
+	{Name: "Docker memory", Critical: true, Run: checkDockerMemory},
+	{Name: "Cluster ports", Critical: true, Run: checkClusterPorts},
+	{Name: "Forward ports", Run: checkForwardPorts},
+	{Name: "Registry name resolution", Run: checkRegistryResolution},
+	{Name: "Existing cluster", Run: checkExistingCluster},
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	config, err := readConfig(doctorTargetDir)
+	if err != nil {
+		printDiagnosis("Configuration", diagnosis{Result: checkFail, Detail: err.Error(),
+			Fix: "fix .gitops-config.yaml or recreate it with gitops init"})
+		return fmt.Errorf("configuration is invalid")
+	}
+
+	fmt.Fprintf(progress, "🩺 Checking the environment for cluster %s...\n", config.ClusterName)
+	counts := map[string]int{}
+	for _, check := range doctorChecks {
+		result := check.Run(config)
+		printDiagnosis(check.Name, result)
+		counts[result.Result]++
+	}
+
+	fmt.Fprintf(progress, "\n📋 %d passed, %d warning(s), %d failed\n", counts[checkPass], counts[checkWarn], counts[checkFail])
+	if counts[checkFail] > 0 {
+		return fmt.Errorf("%d check(s) failed", counts[checkFail])
+	}
+	return nil
+}
+
+// runCriticalChecks runs the critical doctor checks and fails on the first failure
+func runCriticalChecks(config *Config) error {
+	for _, check := range doctorChecks {
+		if !check.Critical {
+			continue
+		}
+		result := check.Run(config)
+		if verbose || result.Result != checkPass {
+			printDiagnosis(check.Name, result)
+		}
+		if result.Result == checkFail {
+			return fmt.Errorf("%s: %s (run gitops doctor for all checks)", check.Name, result.Detail)
+		}
+	}
+	return nil
+}
+
+// printDiagnosis prints the result of a check and, for problems, the fix
+func printDiagnosis(name string, d diagnosis) {
+	icon := "✅"
+	switch d.Result {
+	case checkWarn:
+		icon = "⚠️ "
+	case checkFail:
+		icon = "❌"
+	}
+	fmt.Fprintf(progress, "%s %s: %s\n", icon, name, d.Detail)
+	if d.Result != checkPass && d.Fix != "" {
+		fmt.Fprintf(progress, "   ↳ %s\n", d.Fix)
+	}
+}
+
+func checkDockerMemory(config *Config) diagnosis {
+	output, err := exec.Command("docker", "info", "--format", "{{.MemTotal}}").Output()
+	if err != nil {
+		return diagnosis{Result: checkWarn, Detail: "could not read the Docker memory limit", Fix: "make sure the Docker daemon is running"}
+	}
+	memory, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return diagnosis{Result: checkWarn, Detail: "could not read the Docker memory limit", Fix: "check that 'docker info' works"}
+	}
+
+	detail := fmt.Sprintf("%.1f GiB available to Docker", float64(memory)/(1<<30))
+	fix := "give Docker at least 4 GiB of memory (Docker Desktop: Settings → Resources)"
+	switch {
+	case memory < dockerMemoryMinimum:
+		return diagnosis{Result: checkFail, Detail: detail + ", ArgoCD needs at least 2 GiB", Fix: fix}
+	case memory < dockerMemoryRecommended:
+		return diagnosis{Result: checkWarn, Detail: detail + ", pods may be evicted", Fix: fix}
+	}
+	return diagnosis{Result: checkPass, Detail: detail}
+}
+
+func checkClusterPorts(config *Config) diagnosis {
+	// A running cluster of this environment holds the ports itself
+	if cluster, ok := findK3dCluster(config.ClusterName); ok && cluster.running() {
+		return diagnosis{Result: checkPass, Detail: "held by cluster " + config.ClusterName}
+	}
+	ports := clusterHostPorts(config)
+	if busy := busyPorts(ports); len(busy) > 0 {
+		return diagnosis{Result: checkFail, Detail: "port(s) " + strings.Join(busy, ", ") + " already in use",
+			Fix: "stop the process or cluster using them (lsof -i :" + busy[0] + ", gitops list), or change cluster_port"}
+	}
+	return diagnosis{Result: checkPass, Detail: strings.Join(ports, ", ") + " are free"}
+}
+
+func checkForwardPorts(config *Config) diagnosis {
+	forwards := []struct{ key, port string }{
+		{"argocd_port", config.ArgoCDPort},
+		{"chartmuseum_port", config.ChartMuseumPort},
+		{"git_server_port", config.GitServerPort},
+	}
+	var busy []string
+	for _, forward := range forwards {
+		if len(busyPorts([]string{forward.port})) > 0 {
+			busy = append(busy, fmt.Sprintf("%s (%s)", forward.port, forward.key))
+		}
+	}
+	if len(busy) > 0 {
+		return diagnosis{Result: checkWarn, Detail: "in use: " + strings.Join(busy, ", "),
+			Fix: "stop a running 'gitops port-forward' or the process using the port, or change the key in .gitops-config.yaml"}
+	}
+	return diagnosis{Result: checkPass, Detail: fmt.Sprintf("%s, %s and %s are free", config.ArgoCDPort, config.ChartMuseumPort, config.GitServerPort)}
+}
+
+func checkRegistryResolution(config *Config) diagnosis {
+	host := "k3d-" + config.RegistryName
+	addrs, err := net.LookupHost(host)
+	if err == nil {
+		for _, addr := range addrs {
+			if ip := net.ParseIP(addr); ip != nil && ip.IsLoopback() {
+				return diagnosis{Result: checkPass, Detail: host + " resolves to " + addr}
+			}
+		}
+	}
+	return diagnosis{Result: checkWarn, Detail: host + " does not resolve to localhost",
+		Fix: fmt.Sprintf("add '127.0.0.1 %s' to /etc/hosts, or push images to localhost:%s", host, config.RegistryPort)}
+}
+
+func checkExistingCluster(config *Config) diagnosis {
+	cluster, ok := findK3dCluster(config.ClusterName)
+	switch {
+	case !ok:
+		return diagnosis{Result: checkPass, Detail: "no cluster named " + config.ClusterName + " yet"}
+	case !cluster.running():
+		return diagnosis{Result: checkWarn, Detail: "cluster " + config.ClusterName + " exists but is stopped",
+			Fix: "start it with 'k3d cluster start " + config.ClusterName + "' or remove it with 'gitops cleanup'"}
+	default:
+		return diagnosis{Result: checkWarn, Detail: "cluster " + config.ClusterName + " already exists and setup will reuse it",
+			Fix: "run 'gitops cleanup' first for a fresh environment"}
+	}
+}
+
+// busyPorts returns the ports on which nothing can listen because they are in use
+func busyPorts(ports []string) []string {
+	var busy []string
+	for _, port := range ports {
+		listener, err := net.Listen("tcp", ":"+port)
+		if err != nil {
+			busy = append(busy, port)
+			continue
+		}
+		listener.Close()
+	}
+	return busy
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events [application]",
+	Short: "Show Warning events of ArgoCD Applications",
+	Long: `Lists the Kubernetes Warning events of the resources of one or all ArgoCD Applications,
+including the pods, replicasets and jobs their workloads create. Repeated events are shown once
+with a count, and recognised failures come with a hint on how to fix them.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEvents,
+}
+
+var (
+	eventsTargetDir string
+	eventsSince     time.Duration
+	eventsFollow    bool
+)
+
+func init() {
+	eventsCmd.Flags().StringVar(&eventsTargetDir, "target-dir", ".", "Target directory containing .gitops-config.yaml")
+	eventsCmd.Flags().DurationVar(&eventsSince, "since", time.Hour, "Only show events seen within this duration")
+	eventsCmd.Flags().BoolVarP(&eventsFollow, "follow", "f", false, "Keep printing new events")
+}
+
+// warningEvent is a Kubernetes Warning event
+type warningEvent struct {
+	Namespace string
+	Kind      string
+	Name      string
+	Reason    string
+	Message   string
+	Count     int
+	LastSeen  time.Time
+}
+
+// key identifies repetitions of the same event
+func (e warningEvent) key() string {
+	return strings.Join([]string{e.Namespace, e.Kind, e.Name, e.Reason, e.Message}, "\x00")
+}
+
+// kubeEvent is the part of a Kubernetes Event the CLI reads
+type kubeEvent struct {
+	Metadata struct {
+		Namespace         string `json:"namespace"`
+		CreationTimestamp string `json:"creationTimestamp"`
+	} `json:"metadata"`
+	InvolvedObject struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	} `json:"involvedObject"`
+	Reason        string `json:"reason"`
+	Message       string `json:"message"`
+	Count         int    `json:"count"`
+	LastTimestamp string `json:"lastTimestamp"`
+	EventTime     string `json:"eventTime"`
+}
+
+// warningEvent converts the event, flattening its message to one line
+func (e kubeEvent) warningEvent() warningEvent {
+	event := warningEvent{
+		Namespace: e.Metadata.Namespace,
+		Kind:      e.InvolvedObject.Kind,
+		Name:      e.InvolvedObject.Name,
+		Reason:    e.Reason,
+		Message:   strings.Join(strings.Fields(e.Message), " "),
+		Count:     e.Count,
+	}
+	if event.Count == 0 {
+		event.Count = 1
+	}
+	// Newer events only set eventTime; fall back to when the object was created
+	for _, timestamp := range []string{e.LastTimestamp, e.EventTime, e.Metadata.CreationTimestamp} {
+		if t, err := time.Parse(time.RFC3339, timestamp); err == nil {
+			event.LastSeen = t
+			break
+		}
+	}
+	return event
+}
+
+// listWarningEvents returns the Warning events seen within since, newest first and
+// with repetitions merged. A zero since returns all of them.
+func listWarningEvents(since time.Duration) ([]warningEvent, error) {
+	cmd := exec.Command("kubectl", "get", "events", "--all-namespaces", "--field-selector", "type=Warning", "-o", "json")
+	output, err := runCommand(cmd, "kubectl get events")
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Items []kubeEvent `json:"items"`
+	}
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode events: %w", err)
+	}
+
+	merged := map[string]*warningEvent{}
+	var events []*warningEvent
+	for _, item := range list.Items {
+		event := item.warningEvent()
+		if since > 0 && time.Since(event.LastSeen) > since {
+			continue
+		}
+		if existing, ok := merged[event.key()]; ok {
+			existing.Count += event.Count
+			if event.LastSeen.After(existing.LastSeen) {
+				existing.LastSeen = event.LastSeen
+			}
+			continue
+		}
+		merged[event.key()] = &event
+		events = append(events, &event)
+	}
+
+	result := make([]warningEvent, 0, len(events))
+	for _, event := range events {
+		result = append(result, *event)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].LastSeen.After(result[j].LastSeen) })
+	return result, nil
+}
+
+func runEvents(cmd *cobra.Command, args []string) error {
+	config, err := readConfig(eventsTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := setKubeconfig(config.ClusterName); err != nil {
+		return fmt.Errorf("failed to set kubeconfig: %w", err)
+	}
+
+	var apps []applicationStatus
+	if len(args) == 1 {
+		app, err := findApplication(args[0])
+		if err != nil {
+			return err
+		}
+		apps = append(apps, app)
+	} else {
+		list, err := listArgoCDApplications()
+		if err != nil {
+			return fmt.Errorf("failed to get ArgoCD applications: %w", err)
+		}
+		for _, app := range list {
+			apps = append(apps, newApplicationStatus(app))
+		}
+	}
+
+	events, err := listWarningEvents(eventsSince)
+	if err != nil {
+		return fmt.Errorf("failed to list events: %w", err)
+	}
+	owners, err := loadOwnerIndex()
+	if err != nil {
+		return fmt.Errorf("failed to list workloads: %w", err)
+	}
+
+	// Events are listed oldest first so the most recent end up next to the prompt
+	shown := map[string]warningEvent{}
+	for i := len(events) - 1; i >= 0; i-- {
+		if owner, ok := eventApplication(events[i], apps, owners); ok {
+			printWarningEvent(cmd.OutOrStdout(), config, owner, events[i])
+			shown[events[i].key()] = events[i]
+		}
+	}
+
+	if !eventsFollow {
+		if len(shown) == 0 {
+			fmt.Fprintf(progress, "✅ No Warning events in the last %s\n", eventsSince)
+		}
+		return nil
+	}
+
+	fmt.Fprintln(progress, "👀 Following new Warning events (Ctrl-C to stop)...")
+	return followWarningEvents(cmd.OutOrStdout(), config, apps, owners, shown)
+}
+
+// followWarningEvents prints Warning events of the Applications as they are
+// recorded, skipping repetitions already shown
+func followWarningEvents(out io.Writer, config *Config, apps []applicationStatus, owners ownerIndex, shown map[string]warningEvent) error {
+	watch := exec.Command("kubectl", "get", "events", "--all-namespaces", "--field-selector", "type=Warning",
+		"--watch-only", "-o", "json")
+	stdout, err := watch.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := watch.Start(); err != nil {
+		return fmt.Errorf("failed to watch events: %w", err)
+	}
+	defer watch.Process.Kill()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	updates := make(chan kubeEvent)
+	go func() {
+		defer close(updates)
+		decoder := json.NewDecoder(stdout)
+		for {
+			var event kubeEvent
+			if decoder.Decode(&event) != nil {
+				return
+			}
+			updates <- event
+		}
+	}()
+
+	for {
+		select {
+		case <-signals:
+			return nil
+		case item, ok := <-updates:
+			if !ok {
+				return fmt.Errorf("event watch ended")
+			}
+			event := item.warningEvent()
+			previous, seen := shown[event.key()]
+			if seen && !event.LastSeen.After(previous.LastSeen) {
+				continue
+			}
+			owner, ok := eventApplication(event, apps, owners)
+			if !ok {
+				// Pods created since the index was read are not in it yet
+				_, known := owners[objectRef{event.Namespace, event.Kind, event.Name}]
+				if known || !ownedKinds[event.Kind] {
+					continue
+				}
+				if owners, err = loadOwnerIndex(); err != nil {
+					return fmt.Errorf("failed to list workloads: %w", err)
+				}
+				if owner, ok = eventApplication(event, apps, owners); !ok {
+					continue
+				}
+			}
+			shown[event.key()] = event
+			printWarningEvent(out, config, owner, event)
+		}
+	}
+}
+
+// objectRef identifies a Kubernetes object
+type objectRef struct {
+	Namespace string
+	Kind      string
+	Name      string
+}
+
+// ownerIndex maps pods, replicasets and jobs to the object that controls them, so
+// events of generated objects lead to the workload an Application manages
+type ownerIndex map[objectRef]objectRef
+
+// ownedKinds are the generated kinds the owner index covers
+var ownedKinds = map[string]bool{"Pod": true, "ReplicaSet": true, "Job": true}
+
+// loadOwnerIndex reads the controller owner references of pods, replicasets and jobs
+func loadOwnerIndex() (ownerIndex, error) {
+	cmd := exec.Command("kubectl", "get", "pods,replicasets,jobs", "--all-namespaces", "-o", "json")
+	output, err := runCommand(cmd, "kubectl get pods,replicasets,jobs")
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Items []struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Namespace       string `json:"namespace"`
+				Name            string `json:"name"`
+				OwnerReferences []struct {
+					Kind       string `json:"kind"`
+					Name       string `json:"name"`
+					Controller bool   `json:"controller"`
+				} `json:"ownerReferences"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode owner references: %w", err)
+	}
+
+	owners := ownerIndex{}
+	for _, item := range list.Items {
+		for _, owner := range item.Metadata.OwnerReferences {
+			if owner.Controller {
+				object := objectRef{item.Metadata.Namespace, item.Kind, item.Metadata.Name}
+				owners[object] = objectRef{item.Metadata.Namespace, owner.Kind, owner.Name}
+			}
+		}
+	}
+	return owners, nil
+}
+
+// eventApplication returns the Application managing the object of an event, or the
+// object controlling it such as the Deployment of a pod's ReplicaSet
+func eventApplication(event warningEvent, apps []applicationStatus, owners ownerIndex) (string, bool) {
+	object := objectRef{event.Namespace, event.Kind, event.Name}
+	// Pods are at most three controllers away from a managed object (CronJob, Job, Pod)
+	for depth := 0; depth < 4; depth++ {
+		for _, app := range apps {
+			for _, resource := range app.Resources {
+				if resource.kindName() == object.Kind && resource.Name == object.Name &&
+					(resource.Namespace == object.Namespace || resource.Namespace == "") {
+					return app.Name, true
+				}
+			}
+		}
+		owner, ok := owners[object]
+		if !ok {
+			break
+		}
+		object = owner
+	}
+	return "", false
+}
+
+// printWarningEvent prints an event with its age, Application and hint
+func printWarningEvent(out io.Writer, config *Config, app string, event warningEvent) {
+	repeated := ""
+	if event.Count > 1 {
+		repeated = fmt.Sprintf(" (x%d)", event.Count)
+	}
+	fmt.Fprintf(out, "⚠️  %s ago  %s  %s/%s -n %s  %s%s\n", formatAge(time.Since(event.LastSeen)), app,
+		strings.ToLower(event.Kind), event.Name, event.Namespace, event.Reason, repeated)
+	fmt.Fprintf(out, "    %s\n", event.Message)
+	if hint := eventHint(config, event); hint != "" {
+		fmt.Fprintf(out, "    💡 %s\n", hint)
+	}
+}
+
+// eventHint explains common failure events in terms of the local environment
+func eventHint(config *Config, event warningEvent) string {
+	message := strings.ToLower(event.Message)
+	localRegistry := fmt.Sprintf("%s:%s", config.RegistryName, config.RegistryPort)
+
+	switch {
+	case event.Reason == "Failed" && strings.Contains(message, "pull") ||
+		event.Reason == "ErrImagePull" || event.Reason == "ImagePullBackOff" ||
+		strings.Contains(message, "imagepullbackoff") || strings.Contains(message, "back-off pulling image"):
+		if strings.Contains(message, strings.ToLower(config.RegistryName)) {
+			return fmt.Sprintf("image not found in the local registry %s; push it with docker push localhost:%s/<image>:<tag> and check the tag with gitops registry ls",
+				localRegistry, config.RegistryPort)
+		}
+		return fmt.Sprintf("the image could not be pulled; check its name and tag, or push it to the local registry %s", localRegistry)
+	case event.Reason == "FailedScheduling":
+		switch {
+		case strings.Contains(message, "insufficient"):
+			return "no node has enough free CPU or memory; lower the resource requests"
+		case strings.Contains(message, "taint"):
+			return "every node has a taint the pod does not tolerate; add a toleration or remove the taint"
+		case strings.Contains(message, "persistentvolumeclaim"):
+			return "the pod waits for a PersistentVolumeClaim; check that it exists and is bound"
+		default:
+			return "the scheduler found no node for the pod; check its node selector and affinity"
+		}
+	case strings.Contains(message, "exceeded quota"):
+		return "a ResourceQuota in the namespace blocks the pod; raise the quota or lower the requests"
+	case event.Reason == "BackOff" && strings.Contains(message, "restarting failed container"):
+		return "the container keeps crashing; inspect it with gitops logs"
+	case event.Reason == "Unhealthy":
+		return "a probe is failing; check the probe port and path against what the container serves"
+	case event.Reason == "FailedMount":
+		return "a volume could not be mounted; check that the referenced ConfigMap, Secret or PVC exists"
+	case event.Reason == "OOMKilling" || strings.Contains(message, "oomkilled"):
+		return "the container ran out of memory; raise its memory limit"
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEventApplication(t *testing.T) {
+	// Resources as ArgoCD reports them, with API groups
+	var list []argoCDApplication
+	err := json.Unmarshal([]byte(`[
+		{"metadata": {"name": "api"}, "status": {"resources": [
+			{"group": "apps", "kind": "Deployment", "namespace": "team", "name": "api"},
+			{"group": "batch", "kind": "CronJob", "namespace": "team", "name": "report"},
+			{"kind": "Namespace", "name": "team"}
+		]}},
+		{"metadata": {"name": "gateway"}, "status": {"resources": [
+			{"group": "apps", "kind": "Deployment", "namespace": "team", "name": "api-gateway"}
+		]}}
+	]`), &list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var apps []applicationStatus
+	for _, app := range list {
+		apps = append(apps, newApplicationStatus(app))
+	}
+
+	owners := ownerIndex{
+		{"team", "Pod", "api-7d9f-x2k"}:           {"team", "ReplicaSet", "api-7d9f"},
+		{"team", "ReplicaSet", "api-7d9f"}:        {"team", "Deployment", "api"},
+		{"team", "Pod", "api-gateway-5c8-q1"}:     {"team", "ReplicaSet", "api-gateway-5c8"},
+		{"team", "ReplicaSet", "api-gateway-5c8"}: {"team", "Deployment", "api-gateway"},
+		{"team", "Pod", "report-2890-abc"}:        {"team", "Job", "report-2890"},
+		{"team", "Job", "report-2890"}:            {"team", "CronJob", "report"},
+		{"other", "Pod", "api-7d9f-x2k"}:          {"other", "ReplicaSet", "api-7d9f"},
+		{"team", "Pod", "unmanaged-1"}:            {"team", "ReplicaSet", "unmanaged"},
+		{"team", "ReplicaSet", "api-rogue"}:       {"team", "Deployment", "api-rogue"},
+	}
+
+	tests := []struct {
+		event warningEvent
+		want  string
+	}{
+		{warningEvent{Namespace: "team", Kind: "Deployment", Name: "api"}, "api"},
+		{warningEvent{Namespace: "team", Kind: "Pod", Name: "api-7d9f-x2k"}, "api"},
+		{warningEvent{Namespace: "team", Kind: "Pod", Name: "api-gateway-5c8-q1"}, "gateway"},
+		{warningEvent{Namespace: "team", Kind: "Pod", Name: "report-2890-abc"}, "api"},
+		{warningEvent{Namespace: "team", Kind: "Namespace", Name: "team"}, "api"},
+		// Names that merely start with a managed name belong to no Application
+		{warningEvent{Namespace: "team", Kind: "ReplicaSet", Name: "api-rogue"}, ""},
+		{warningEvent{Namespace: "team", Kind: "Pod", Name: "api-unknown"}, ""},
+		{warningEvent{Namespace: "team", Kind: "Service", Name: "api"}, ""},
+		{warningEvent{Namespace: "other", Kind: "Pod", Name: "api-7d9f-x2k"}, ""},
+		{warningEvent{Namespace: "team", Kind: "Pod", Name: "unmanaged-1"}, ""},
+	}
+	for _, tt := range tests {
+		got, ok := eventApplication(tt.event, apps, owners)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("eventApplication(%s %s/%s) = %q, %v, want %q", tt.event.Kind, tt.event.Namespace, tt.event.Name, got, ok, tt.want)
+		}
+	}
+}
+
+func TestEventHint(t *testing.T) {
+	config := &Config{RegistryName: "myregistry.localhost", RegistryPort: "5001"}
+	tests := []struct {
+		reason  string
+		message string
+		want    string
+	}{
+		{"Failed", `Failed to pull image "k3d-myregistry.localhost:5001/api:1.0": not found`, "not found in the local registry myregistry.localhost:5001"},
+		{"Failed", `Failed to pull image "docker.io/library/ngnix:1.0": pull access denied`, "could not be pulled"},
+		{"BackOff", `Back-off pulling image "api:1.0"`, "could not be pulled"},
+		{"FailedScheduling", "0/1 nodes are available: 1 Insufficient memory.", "enough free CPU or memory"},
+		{"FailedScheduling", "0/1 nodes are available: 1 node(s) had untolerated taint {dedicated: infra}", "taint"},
+		{"FailedScheduling", `0/1 nodes are available: pod has unbound immediate PersistentVolumeClaims`, "PersistentVolumeClaim"},
+		{"FailedScheduling", "0/1 nodes are available: 1 node(s) didn't match Pod's node affinity", "node selector and affinity"},
+		{"FailedCreate", `pods "api-1" is forbidden: exceeded quota: compute`, "ResourceQuota"},
+		{"BackOff", "Back-off restarting failed container api in pod api-1", "gitops logs"},
+		{"Unhealthy", "Readiness probe failed: connection refused", "probe"},
+		{"FailedMount", `MountVolume.SetUp failed for volume "config": configmap "api" not found`, "could not be mounted"},
+		{"OOMKilling", "Memory cgroup out of memory: Killed process 1234", "out of memory"},
+		{"FailedSync", "error determining status", ""},
+	}
+	for _, tt := range tests {
+		got := eventHint(config, warningEvent{Reason: tt.reason, Message: tt.message})
+		if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+			t.Errorf("eventHint(%s, %q) = %q, want it to mention %q", tt.reason, tt.message, got, tt.want)
+		}
+	}
+}
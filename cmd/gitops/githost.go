@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/cgi"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var gitServerCmd = &cobra.Command{
+	Use:   "git-server",
+	Short: "Git server utilities",
+}
+
+var gitServerServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve repositories over smart HTTP from the host",
+	Long: `Runs the git server for git_server_backend: host. Repositories are stored in the project's
+.gitops/git-data directory and served on git_server_port to the host and to the cluster
+(as http://host.k3d.internal:<port>).
+
+By default the server listens only where the cluster reaches the host: the gateway of the
+cluster's docker network on Linux, and 127.0.0.1 with Docker Desktop on macOS. Without
+git_server_auth it refuses any other --address, since anyone reaching it could push.`,
+	RunE: runGitServerServe,
+}
+
+var gitServerCredentialsCmd = &cobra.Command{
+	Use:   "credentials",
+	Short: "Show the git server user and password",
+	RunE:  runGitServerCredentials,
+}
+
+var (
+	gitServerTargetDir string
+	gitServerAddress   string
+)
+
+func init() {
+	gitServerCmd.PersistentFlags().StringVar(&gitServerTargetDir, "target-dir", ".", "Target directory containing .gitops-config.yaml")
+	gitServerServeCmd.Flags().StringVar(&gitServerAddress, "address", "", "Address to listen on (default: the address the k3d cluster reaches the host on)")
+
+	gitServerCmd.AddCommand(gitServerServeCmd)
+	gitServerCmd.AddCommand(gitServerCredentialsCmd)
+}
+
+// initBareRepository creates a bare repository in dataDir unless it exists
+func initBareRepository(dataDir, name string) error {
+	repoPath := filepath.Join(dataDir, name+".git")
+	if _, err := os.Stat(repoPath); err == nil {
+		return nil
+	}
+
+	cmd := exec.Command("git", "init", "--bare", "--quiet", repoPath)
+	if _, err := runCommand(cmd, "git init --bare "+name); err != nil {
+		return err
+	}
+	cmd = exec.Command("git", "--git-dir", repoPath, "config", "http.receivepack", "true")
+	_, err := runCommand(cmd, "git config http.receivepack")
+	return err
+}
+
+// clusterHostAddress returns the address pods reach the host on as host.k3d.internal:
+// the gateway of the cluster's docker network. Docker Desktop forwards that name to the
+// host's loopback instead, so on macOS it is 127.0.0.1.
+func clusterHostAddress(clusterName string) (string, error) {
+	if runtime.GOOS == "darwin" {
+		return "127.0.0.1", nil
+	}
+	output, err := exec.Command("docker", "network", "inspect", "-f", "{{range .IPAM.Config}}{{.Gateway}} {{end}}", "k3d-"+clusterName).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the docker network of cluster %s (is it created?): %w", clusterName, err)
+	}
+	for _, gateway := range strings.Fields(string(output)) {
+		if ip := net.ParseIP(gateway); ip != nil && ip.To4() != nil {
+			return gateway, nil
+		}
+	}
+	return "", fmt.Errorf("docker network k3d-%s has no IPv4 gateway", clusterName)
+}
+
+// checkServeAddress refuses to serve anonymous pushes on an address reachable from
+// outside the host and the cluster: anything but loopback or the cluster's gateway
+func checkServeAddress(address, clusterAddress string, auth bool) error {
+	if auth || address == clusterAddress {
+		return nil
+	}
+	if ip := net.ParseIP(address); (ip != nil && ip.IsLoopback()) || address == "localhost" {
+		return nil
+	}
+	return fmt.Errorf("refusing to serve anonymous, writable repositories on %s; set git_server_auth: true or listen on the cluster network", address)
+}
+
+// branchHeads returns the commit of every branch of a bare repository
+func branchHeads(repoPath string) map[string]string {
+	output, err := exec.Command("git", "--git-dir", repoPath, "for-each-ref", "--format=%(objectname) %(refname:short)", "refs/heads").Output()
+	if err != nil {
+		return nil
+	}
+	heads := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if commit, branch, found := strings.Cut(line, " "); found {
+			heads[branch] = commit
+		}
+	}
+	return heads
+}
+
+// gitHTTPHandler serves the bare repositories in dataDir through git http-backend.
+// When user is set, requests must authenticate with HTTP basic auth. onPush is called
+// for every branch a push changed.
+func gitHTTPHandler(dataDir, user, password string, onPush func(repository, branch string)) (http.Handler, error) {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		return nil, fmt.Errorf("git is required for the host git server: %w", err)
+	}
+
+	backend := &cgi.Handler{
+		Path: gitPath,
+		Args: []string{"http-backend"},
+		Env: []string{
+			"GIT_PROJECT_ROOT=" + dataDir,
+			"GIT_HTTP_EXPORT_ALL=1",
+		},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if verbose {
+			fmt.Fprintf(progress, "🔧 %s %s\n", r.Method, r.URL)
+		}
+		if r.URL.Path == "/" {
+			fmt.Fprintln(w, "local-gitops git server")
+			return
+		}
+		if user != "" {
+			u, p, ok := r.BasicAuth()
+			if !ok || subtle.ConstantTimeCompare([]byte(u), []byte(user)) != 1 || subtle.ConstantTimeCompare([]byte(p), []byte(password)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+				http.Error(w, "authentication required", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		repoPath, isPush := strings.CutSuffix(r.URL.Path, "/git-receive-pack")
+		if !isPush || r.Method != http.MethodPost || onPush == nil {
+			backend.ServeHTTP(w, r)
+			return
+		}
+
+		repoPath = filepath.Join(dataDir, filepath.Clean("/"+repoPath))
+		before := branchHeads(repoPath)
+		backend.ServeHTTP(w, r)
+		repository := strings.TrimSuffix(filepath.Base(repoPath), ".git")
+		for branch, commit := range branchHeads(repoPath) {
+			if before[branch] != commit {
+				go onPush(repository, branch)
+			}
+		}
+	}), nil
+}
+
+func runGitServerServe(cmd *cobra.Command, args []string) error {
+	config, err := readConfig(gitServerTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if config.GitServerBackend != gitBackendHost {
+		fmt.Fprintf(progress, "⚠️  git_server_backend is %s; the cluster will not use this server\n", config.GitServerBackend)
+	}
+
+	dataDir, err := gitServerDataDir(gitServerTargetDir)
+	if err != nil {
+		return err
+	}
+	store, err := newSecretStore(config, gitServerTargetDir)
+	if err != nil {
+		return err
+	}
+	user, password, err := gitServerCredentials(config, store)
+	if err != nil {
+		return err
+	}
+
+	// Refresh ArgoCD after every push, as Gitea does with its webhook
+	var onPush func(repository, branch string)
+	if err := setKubeconfig(config.ClusterName); err != nil {
+		fmt.Fprintf(progress, "⚠️  Cluster %s is not reachable, pushes will not notify ArgoCD: %v\n", config.ClusterName, err)
+	} else {
+		onPush = func(repository, branch string) {
+			if err := postArgoCDWebhook(config, repository, branch); err != nil {
+				fmt.Fprintf(progress, "⚠️  Failed to notify ArgoCD about %s/%s: %v\n", repository, branch, err)
+			} else if verbose {
+				fmt.Fprintf(progress, "🔔 Notified ArgoCD about %s/%s\n", repository, branch)
+			}
+		}
+	}
+
+	address := gitServerAddress
+	clusterAddress, err := clusterHostAddress(config.ClusterName)
+	if address == "" {
+		if err != nil {
+			return fmt.Errorf("%w; pass --address to choose where to listen", err)
+		}
+		address = clusterAddress
+	}
+	if err := checkServeAddress(address, clusterAddress, config.GitServerAuth); err != nil {
+		return err
+	}
+	if address != clusterAddress && !config.GitServerAuth {
+		fmt.Fprintf(progress, "⚠️  Listening on %s, which the cluster may not reach as host.k3d.internal\n", address)
+	}
+
+	handler, err := gitHTTPHandler(dataDir, user, password, onPush)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:              net.JoinHostPort(address, config.GitServerPort),
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	fmt.Fprintf(progress, "📁 Serving %s on http://%s (Ctrl+C to stop)\n", dataDir, server.Addr)
+	fmt.Fprintf(progress, "   In-cluster URL: http://host.k3d.internal:%s/<name>.git\n", config.GitServerPort)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	select {
+	case err := <-errs:
+		return fmt.Errorf("git server failed: %w", err)
+	case <-signals:
+	}
+
+	fmt.Fprintln(progress, "\n🛑 Stopping git server...")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return server.Shutdown(ctx)
+}
+
+func runGitServerCredentials(cmd *cobra.Command, args []string) error {
+	config, err := readConfig(gitServerTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	key, user := gitServerPasswordKey, gitServerUser
+	switch {
+	case config.GitServerBackend == gitBackendGitea:
+		key, user = giteaAdminPasswordKey, giteaAdminUser
+	case !config.GitServerAuth:
+		fmt.Fprintf(progress, "ℹ️  The %s git server accepts anonymous access (set git_server_auth: true to require credentials)\n", config.GitServerBackend)
+		return nil
+	}
+
+	store, err := newSecretStore(config, gitServerTargetDir)
+	if err != nil {
+		return err
+	}
+	password, err := store.get(key)
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		return fmt.Errorf("no git server password stored for cluster %s. Please run 'gitops setup' first", config.ClusterName)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "user: %s\npassword: %s\n", user, password)
+	return nil
+}
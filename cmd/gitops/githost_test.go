@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestCheckServeAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		auth    bool
+		wantErr bool
+	}{
+		{"172.18.0.1", false, false},
+		{"127.0.0.1", false, false},
+		{"::1", false, false},
+		{"localhost", false, false},
+		{"0.0.0.0", false, true},
+		{"192.168.1.20", false, true},
+		{"0.0.0.0", true, false},
+		{"192.168.1.20", true, false},
+	}
+	for _, tt := range tests {
+		err := checkServeAddress(tt.address, "172.18.0.1", tt.auth)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkServeAddress(%q, auth=%v) error = %v, want error %v", tt.address, tt.auth, err, tt.wantErr)
+		}
+	}
+}
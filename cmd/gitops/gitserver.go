@@ -0,0 +1,794 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Git server backends selectable with git_server_backend in .gitops-config.yaml
+const (
+	gitBackendBasic = "basic"
+	gitBackendGitea = "gitea"
+	gitBackendHost  = "host"
+)
+
+// manifestRepository is the repository deploy pushes the manifest directory to
+const manifestRepository = "manifest"
+
+// Git server authentication settings, used when git_server_auth is enabled
+const (
+	gitServerUser           = "gitops"
+	gitServerPasswordKey    = "git-server-password"
+	gitServerAuthProxyImage = "nginx:1.27-alpine"
+	gitServerAuthSecretName = "git-server-auth"
+	gitServerAuthProxyPort  = 8081
+)
+
+// Gitea settings
+const (
+	defaultGiteaVersion    = "1.22.3"
+	giteaImageRepository   = "gitea/gitea"
+	giteaAdminUser         = "gitops"
+	giteaAdminPasswordKey  = "gitea-admin-password"
+	giteaInitImage         = "busybox:1.36"
+	gitServerClusterDomain = "git-server.git-server.svc.cluster.local"
+)
+
+// gitBackend is a git server that hosts the project's repositories
+type gitBackend interface {
+	// Install deploys the server from its rendered manifest and waits until it is ready
+	Install(manifest string) error
+	// Connect makes the server reachable from the host; the returned function disconnects
+	Connect() (func(), error)
+	// CreateRepo creates a repository unless it exists. Requires Connect.
+	CreateRepo(name string) error
+	// ListRepos returns the names of all repositories. Requires Connect.
+	ListRepos() ([]string, error)
+	// DeleteRepo removes a repository and its history. Requires Connect.
+	DeleteRepo(name string) error
+	// CommitMessage returns the subject of a commit in a repository. It reads the
+	// repository in place and does not need Connect, so status stays fast.
+	CommitMessage(name, revision string) (string, error)
+	// PushURL is the URL the git container on the host pushes to; runGitContainer
+	// supplies the credentials
+	PushURL(name string) string
+	// Credentials returns the user and password clients authenticate with, or empty
+	// strings when the server accepts anonymous access
+	Credentials() (string, string, error)
+	// PushCredentials returns the user and password the git container pushes with
+	PushCredentials() (string, string, error)
+}
+
+// newGitBackend returns the git server backend configured for the project in targetDir
+func newGitBackend(config *Config, targetDir string) (gitBackend, error) {
+	store, err := newSecretStore(config, targetDir)
+	if err != nil {
+		return nil, err
+	}
+
+	switch config.GitServerBackend {
+	case gitBackendBasic:
+		return &basicGitBackend{config: config, store: store}, nil
+	case gitBackendGitea:
+		return &giteaGitBackend{config: config, store: store}, nil
+	case gitBackendHost:
+		dataDir, err := gitServerDataDir(targetDir)
+		if err != nil {
+			return nil, err
+		}
+		return &hostGitBackend{config: config, store: store, dataDir: dataDir}, nil
+	default:
+		return nil, fmt.Errorf("unknown git_server_backend %q (use basic, gitea or host)", config.GitServerBackend)
+	}
+}
+
+// gitRepoURL returns the URL ArgoCD clones a repository from
+func gitRepoURL(config *Config, name string) string {
+	if config.GitServerBackend == gitBackendHost {
+		return fmt.Sprintf("http://host.k3d.internal:%s%s", config.GitServerPort, gitRepoPath(config, name))
+	}
+	return fmt.Sprintf("http://%s%s", gitServerClusterDomain, gitRepoPath(config, name))
+}
+
+// gitRepoPath returns the HTTP path of a repository on the git server
+func gitRepoPath(config *Config, name string) string {
+	if config.GitServerBackend == gitBackendGitea {
+		return fmt.Sprintf("/%s/%s.git", giteaAdminUser, name)
+	}
+	return fmt.Sprintf("/%s.git", name)
+}
+
+// gitRepoName returns the repository name of an in-cluster URL built by gitRepoURL
+func gitRepoName(config *Config, url string) (string, bool) {
+	prefix, suffix, _ := strings.Cut(gitRepoURL(config, "\x00"), "\x00")
+	if !strings.HasPrefix(url, prefix) || !strings.HasSuffix(url, suffix) || len(url) <= len(prefix)+len(suffix) {
+		return "", false
+	}
+	return url[len(prefix) : len(url)-len(suffix)], true
+}
+
+// gitServerImage returns the image of the in-cluster git server, or "" for the host backend
+func gitServerImage(config *Config) string {
+	switch config.GitServerBackend {
+	case gitBackendGitea:
+		return fmt.Sprintf("%s:%s-rootless", giteaImageRepository, config.GiteaVersion)
+	case gitBackendHost:
+		return ""
+	default:
+		return gitServerImageRepository + ":" + config.GitServerVersion
+	}
+}
+
+// renderGitServerManifest renders the in-cluster manifest of the configured backend
+func renderGitServerManifest(config *Config, image string) string {
+	switch config.GitServerBackend {
+	case gitBackendGitea:
+		return giteaManifest(image, config.ClusterName, config.GitServerAuth)
+	case gitBackendHost:
+		return ""
+	default:
+		if config.GitServerAuth {
+			return gitServerAuthManifest(image, gitServerAuthProxyImage, config.ClusterName)
+		}
+		return gitServerManifest(image, config.ClusterName, gitServerPod{targetPort: 8080})
+	}
+}
+
+// storedPassword returns the password stored under key, generating and storing one if needed
+func storedPassword(store *secretStore, key string) (string, error) {
+	password, err := store.get(key)
+	if err != nil || password != "" {
+		return password, err
+	}
+
+	if password, err = generatePassword(20); err != nil {
+		return "", err
+	}
+	if _, err := store.set(key, password); err != nil {
+		return "", err
+	}
+	return password, nil
+}
+
+// gitServerCredentials returns the shared git server credentials when authentication
+// is enabled, or empty strings for anonymous access
+func gitServerCredentials(config *Config, store *secretStore) (string, string, error) {
+	if !config.GitServerAuth {
+		return "", "", nil
+	}
+	password, err := storedPassword(store, gitServerPasswordKey)
+	if err != nil {
+		return "", "", err
+	}
+	return gitServerUser, password, nil
+}
+
+// hostGitURL returns the http URL of a repository on host.docker.internal
+func hostGitURL(config *Config, name string) string {
+	return fmt.Sprintf("http://host.docker.internal:%s%s", config.GitServerPort, gitRepoPath(config, name))
+}
+
+// shellQuote quotes a value as a single word of a POSIX shell script
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// gitCredentialsPath is where runGitContainer mounts the git credential store
+const gitCredentialsPath = "/run/gitops/git-credentials"
+
+// runGitContainer runs a shell script in the git client image with the given bind mounts.
+// The backend's credentials reach git through a mounted credential store file, so they
+// appear neither in a command line nor in the container configuration.
+func runGitContainer(config *Config, backend gitBackend, mounts []string, script string) error {
+	user, password, err := backend.PushCredentials()
+	if err != nil {
+		return err
+	}
+	if user != "" {
+		credentialsFile, err := writeGitCredentials(config, user, password)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(credentialsFile)
+		mounts = append(mounts, credentialsFile+":"+gitCredentialsPath+":ro")
+		script = fmt.Sprintf("git config --global credential.helper 'store --file=%s'\n%s", gitCredentialsPath, script)
+	}
+
+	args := []string{"run", "--rm", "--entrypoint=", "--add-host", "host.docker.internal:host-gateway"}
+	for _, mount := range mounts {
+		args = append(args, "-v", mount)
+	}
+	args = append(args, gitImage(config), "/bin/sh", "-c", script)
+
+	dockerCmd := exec.Command("docker", args...)
+	if verbose {
+		dockerCmd.Stdout = progress
+		dockerCmd.Stderr = os.Stderr
+		return dockerCmd.Run()
+	}
+
+	output, err := dockerCmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w\n%s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// writeGitCredentials writes a private git credential store file for the git server
+func writeGitCredentials(config *Config, user, password string) (string, error) {
+	file, err := os.CreateTemp("", "gitops-credentials-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create credentials file: %w", err)
+	}
+	defer file.Close()
+
+	line := fmt.Sprintf("http://%s@host.docker.internal:%s\n", url.UserPassword(user, password).String(), config.GitServerPort)
+	if _, err := file.WriteString(line); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// waitForHTTP polls a URL until it answers with any HTTP status
+func waitForHTTP(url string, timeout time.Duration) error {
+	return waitForHTTPClient(&http.Client{Timeout: 2 * time.Second}, url, timeout)
+}
+
+// waitForHTTPClient polls a URL with client until it answers with any HTTP status
+func waitForHTTPClient(client *http.Client, url string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		resp, err := client.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s did not respond within %s: %w", url, timeout, err)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// basicGitBackend is moikot/basic-git-server: anonymous smart HTTP over /repos
+type basicGitBackend struct {
+	config *Config
+	store  *secretStore
+}
+
+// gitServerAuthManifest deploys the basic Git server behind an nginx proxy that
+// requires HTTP basic auth from the git-server-auth Secret
+func gitServerAuthManifest(image, proxyImage, clusterName string) string {
+	manifest := gitServerManifest(image, clusterName, gitServerPod{
+		annotations: `
+      annotations:
+        kubectl.kubernetes.io/default-container: git-server`,
+		containers: fmt.Sprintf(`
+        - name: auth-proxy
+          image: %s
+          imagePullPolicy: IfNotPresent
+          ports:
+            - containerPort: %d
+          volumeMounts:
+            - mountPath: /etc/nginx/conf.d
+              name: auth-proxy-config
+            - mountPath: /etc/nginx/auth
+              name: auth-proxy-users`, proxyImage, gitServerAuthProxyPort),
+		volumes: fmt.Sprintf(`
+        - name: auth-proxy-config
+          configMap:
+            name: git-server-auth-proxy
+        - name: auth-proxy-users
+          secret:
+            secretName: %s`, gitServerAuthSecretName),
+		targetPort: gitServerAuthProxyPort,
+	})
+
+	return manifest + fmt.Sprintf(`
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: git-server-auth-proxy
+  namespace: git-server
+data:
+  default.conf: |
+    server {
+      listen %d;
+      client_max_body_size 0;
+      location / {
+        auth_basic "git";
+        auth_basic_user_file /etc/nginx/auth/htpasswd;
+        proxy_pass http://127.0.0.1:8080;
+        proxy_set_header Host $host;
+        proxy_request_buffering off;
+      }
+    }`, gitServerAuthProxyPort)
+}
+
+// htpasswdEntry returns the htpasswd line of user with a bcrypt hash of password,
+// using the $2y$ prefix htpasswd writes
+func htpasswdEntry(user, password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash git server password: %w", err)
+	}
+	return fmt.Sprintf("%s:$2y$%s\n", user, strings.TrimPrefix(string(hash), "$2a$")), nil
+}
+
+// applyGitServerAuthSecret stores the htpasswd entry of the git server user in the cluster
+func applyGitServerAuthSecret(user, password string) error {
+	htpasswd, err := htpasswdEntry(user, password)
+	if err != nil {
+		return err
+	}
+
+	secret := fmt.Sprintf(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: git-server
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: %s
+  namespace: git-server
+data:
+  htpasswd: %s
+`, gitServerAuthSecretName, base64.StdEncoding.EncodeToString([]byte(htpasswd)))
+
+	cmd := exec.Command("kubectl", "apply", "-f", "-")
+	cmd.Stdin = strings.NewReader(secret)
+	_, err = runCommand(cmd, "kubectl apply Git server auth secret")
+	return err
+}
+
+func (b *basicGitBackend) Install(manifest string) error {
+	user, password, err := b.Credentials()
+	if err != nil {
+		return err
+	}
+	if user != "" {
+		fmt.Fprintln(progress, "🔐 Configuring Git server authentication...")
+		if err := applyGitServerAuthSecret(user, password); err != nil {
+			return err
+		}
+	}
+	return setupGitServer(manifest)
+}
+
+func (b *basicGitBackend) Connect() (func(), error) {
+	return startGitServerPortForward(b.config, "/")
+}
+
+func (b *basicGitBackend) CreateRepo(name string) error {
+	script := fmt.Sprintf("test -d /repos/%[1]s.git || git init --bare --quiet /repos/%[1]s.git", name)
+	cmd := exec.Command("kubectl", "exec", "-n", "git-server", "deploy/git-server", "--", "sh", "-c", script)
+	_, err := runCommand(cmd, "create repository "+name)
+	return err
+}
+
+func (b *basicGitBackend) ListRepos() ([]string, error) {
+	cmd := exec.Command("kubectl", "exec", "-n", "git-server", "deploy/git-server", "--", "sh", "-c", "cd /repos && ls -1d *.git 2>/dev/null || true")
+	output, err := runCommand(cmd, "list repositories")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, line := range strings.Fields(string(output)) {
+		names = append(names, strings.TrimSuffix(line, ".git"))
+	}
+	return names, nil
+}
+
+func (b *basicGitBackend) CommitMessage(name, revision string) (string, error) {
+	cmd := exec.Command("kubectl", "exec", "-n", "git-server", "deploy/git-server", "--",
+		"git", "--git-dir", "/repos/"+name+".git", "log", "-1", "--format=%s", revision)
+	output, err := runCommand(cmd, "git log "+name)
+	return strings.TrimSpace(string(output)), err
+}
+
+func (b *basicGitBackend) DeleteRepo(name string) error {
+	cmd := exec.Command("kubectl", "exec", "-n", "git-server", "deploy/git-server", "--", "rm", "-rf", "/repos/"+name+".git")
+	_, err := runCommand(cmd, "delete repository "+name)
+	return err
+}
+
+func (b *basicGitBackend) PushURL(name string) string {
+	return hostGitURL(b.config, name)
+}
+
+func (b *basicGitBackend) Credentials() (string, string, error) {
+	return gitServerCredentials(b.config, b.store)
+}
+
+func (b *basicGitBackend) PushCredentials() (string, string, error) {
+	return b.Credentials()
+}
+
+// giteaGitBackend is a Gitea server with an admin user owning all repositories
+type giteaGitBackend struct {
+	config *Config
+	store  *secretStore
+}
+
+// giteaManifest deploys rootless Gitea on the persistent repository volume. With
+// requireSignIn, repositories are private and cloning requires credentials.
+func giteaManifest(image, clusterName string, requireSignIn bool) string {
+	return gitServerStorageManifest(clusterName) + fmt.Sprintf(`
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: git-server
+  namespace: git-server
+spec:
+  replicas: 1
+  strategy:
+    type: Recreate
+  selector:
+    matchLabels:
+      app: git-server
+  template:
+    metadata:
+      labels:
+        app: git-server
+    spec:
+      initContainers:
+        - name: fix-permissions
+          image: %s
+          imagePullPolicy: IfNotPresent
+          command: ["sh", "-c", "mkdir -p /storage/gitea/data /storage/gitea/config && chown -R 1000:1000 /storage/gitea"]
+          volumeMounts:
+            - mountPath: /storage
+              name: repo-storage
+      containers:
+        - name: git-server
+          image: %s
+          imagePullPolicy: IfNotPresent
+          env:
+            - name: GITEA__security__INSTALL_LOCK
+              value: "true"
+            - name: GITEA__server__HTTP_PORT
+              value: "3000"
+            - name: GITEA__server__ROOT_URL
+              value: http://%s/
+            - name: GITEA__database__DB_TYPE
+              value: sqlite3
+            - name: GITEA__service__DISABLE_REGISTRATION
+              value: "true"
+            - name: GITEA__repository__DEFAULT_PRIVATE
+              value: public
+            - name: GITEA__service__REQUIRE_SIGNIN_VIEW
+              value: "%t"
+            - name: GITEA__webhook__ALLOWED_HOST_LIST
+              value: "*"
+          ports:
+            - containerPort: 3000
+          readinessProbe:
+            httpGet:
+              path: /api/healthz
+              port: 3000
+          volumeMounts:
+            - mountPath: /var/lib/gitea
+              name: repo-storage
+              subPath: gitea/data
+            - mountPath: /etc/gitea
+              name: repo-storage
+              subPath: gitea/config
+      volumes:
+        - name: repo-storage
+          persistentVolumeClaim:
+            claimName: git-server-pvc
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: git-server
+  namespace: git-server
+spec:
+  ports:
+    - port: 80
+      targetPort: 3000
+      name: http
+  selector:
+    app: git-server`, giteaInitImage, image, gitServerClusterDomain, requireSignIn)
+}
+
+func (b *giteaGitBackend) password() (string, error) {
+	return storedPassword(b.store, giteaAdminPasswordKey)
+}
+
+func (b *giteaGitBackend) Install(manifest string) error {
+	if err := setupGitServer(manifest); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(progress, "👤 Bootstrapping Gitea admin user...")
+	password, err := b.password()
+	if err != nil {
+		return err
+	}
+
+	// The password is read from stdin so it stays off the kubectl command line
+	cmd := giteaAdminCommand(password, "user create --admin --username "+giteaAdminUser+
+		" --email "+giteaAdminUser+"@example.com --must-change-password=false")
+	if _, err := runCommand(cmd, "gitea admin user create"); err != nil {
+		if !strings.Contains(err.Error(), "already exists") {
+			return err
+		}
+
+		// The user survives cluster recreation; make sure it uses the stored password
+		cmd = giteaAdminCommand(password, "user change-password --username "+giteaAdminUser+" --must-change-password=false")
+		if _, err := runCommand(cmd, "gitea admin user change-password"); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(progress, "✅ Gitea admin user %s ready (password: gitops git-server credentials)\n", giteaAdminUser)
+	return nil
+}
+
+// giteaAdminCommand runs 'gitea admin <args> --password <password>' in the git server
+// pod with the password passed on stdin
+func giteaAdminCommand(password, args string) *exec.Cmd {
+	cmd := exec.Command("kubectl", "exec", "-i", "-n", "git-server", "deploy/git-server", "--",
+		"sh", "-c", `read -r password && exec gitea admin `+args+` --password "$password"`)
+	cmd.Stdin = strings.NewReader(password + "\n")
+	return cmd
+}
+
+func (b *giteaGitBackend) Connect() (func(), error) {
+	return startGitServerPortForward(b.config, "/api/healthz")
+}
+
+// api calls the Gitea REST API through the port forward as the admin user
+func (b *giteaGitBackend) api(method, path string, body interface{}) (*http.Response, error) {
+	password, err := b.password()
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("http://localhost:%s/api/v1%s", b.config.GitServerPort, path), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(giteaAdminUser, password)
+	req.Header.Set("Content-Type", "application/json")
+
+	if verbose {
+		fmt.Fprintf(progress, "🔧 Gitea API: %s %s\n", method, path)
+	}
+	return (&http.Client{Timeout: 30 * time.Second}).Do(req)
+}
+
+func (b *giteaGitBackend) CreateRepo(name string) error {
+	resp, err := b.api(http.MethodPost, "/user/repos", map[string]interface{}{
+		"name":           name,
+		"private":        b.config.GitServerAuth,
+		"default_branch": "master",
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusConflict {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Gitea API returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return b.ensureWebhook(name)
+}
+
+// ensureWebhook makes Gitea notify ArgoCD on every push to a repository. ArgoCD
+// understands Gitea's Gogs-compatible payload.
+func (b *giteaGitBackend) ensureWebhook(name string) error {
+	hooksPath := fmt.Sprintf("/repos/%s/%s/hooks", giteaAdminUser, name)
+	resp, err := b.api(http.MethodGet, hooksPath, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Gitea API returned %s", resp.Status)
+	}
+
+	var hooks []struct {
+		Config map[string]string `json:"config"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&hooks); err != nil {
+		return fmt.Errorf("failed to decode Gitea webhooks: %w", err)
+	}
+	existing := map[string]bool{}
+	for _, hook := range hooks {
+		existing[hook.Config["url"]] = true
+	}
+
+	urls := []string{argoCDWebhookURL}
+	if b.config.ApplicationSet {
+		urls = append(urls, applicationSetWebhookURL)
+	}
+	for _, url := range urls {
+		if existing[url] {
+			continue
+		}
+		resp, err := b.api(http.MethodPost, hooksPath, map[string]interface{}{
+			"type":   "gogs",
+			"active": true,
+			"events": []string{"push"},
+			"config": map[string]string{"url": url, "content_type": "json"},
+		})
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			return fmt.Errorf("failed to create webhook %s: Gitea API returned %s", url, resp.Status)
+		}
+	}
+	return nil
+}
+
+// giteaPageSize is the page size of Gitea list requests; the server may cap it lower
+const giteaPageSize = 50
+
+func (b *giteaGitBackend) ListRepos() ([]string, error) {
+	// Page until an empty page, since Gitea may return fewer items than asked for
+	names := []string{}
+	for page := 1; ; page++ {
+		resp, err := b.api(http.MethodGet, fmt.Sprintf("/user/repos?limit=%d&page=%d", giteaPageSize, page), nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("Gitea API returned %s", resp.Status)
+		}
+		var repos []struct {
+			Name string `json:"name"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&repos)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode Gitea repositories: %w", err)
+		}
+
+		if len(repos) == 0 {
+			return names, nil
+		}
+		for _, repo := range repos {
+			names = append(names, repo.Name)
+		}
+	}
+}
+
+func (b *giteaGitBackend) CommitMessage(name, revision string) (string, error) {
+	// Gitea keeps repositories under <owner>/<name>.git in lower case
+	gitDir := fmt.Sprintf("/var/lib/gitea/git/repositories/%s/%s.git", strings.ToLower(giteaAdminUser), strings.ToLower(name))
+	cmd := exec.Command("kubectl", "exec", "-n", "git-server", "deploy/git-server", "--",
+		"git", "--git-dir", gitDir, "log", "-1", "--format=%s", revision)
+	output, err := runCommand(cmd, "git log "+name)
+	return strings.TrimSpace(string(output)), err
+}
+
+func (b *giteaGitBackend) DeleteRepo(name string) error {
+	resp, err := b.api(http.MethodDelete, fmt.Sprintf("/repos/%s/%s", giteaAdminUser, name), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("Gitea API returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (b *giteaGitBackend) PushURL(name string) string {
+	return hostGitURL(b.config, name)
+}
+
+// Credentials returns the admin user, which owns every repository. Pushes always
+// authenticate; ArgoCD only needs the credentials when git_server_auth is enabled.
+func (b *giteaGitBackend) Credentials() (string, string, error) {
+	if !b.config.GitServerAuth {
+		return "", "", nil
+	}
+	password, err := b.password()
+	if err != nil {
+		return "", "", err
+	}
+	return giteaAdminUser, password, nil
+}
+
+// PushCredentials are the admin user's, since Gitea only accepts authenticated pushes
+func (b *giteaGitBackend) PushCredentials() (string, string, error) {
+	password, err := b.password()
+	if err != nil {
+		return "", "", err
+	}
+	return giteaAdminUser, password, nil
+}
+
+// hostGitBackend serves bare repositories from the project state directory with
+// 'gitops git-server serve' running on the host
+type hostGitBackend struct {
+	config  *Config
+	store   *secretStore
+	dataDir string
+}
+
+func (b *hostGitBackend) Install(manifest string) error {
+	fmt.Fprintf(progress, "📁 Git repositories are served from %s on the host\n", b.dataDir)
+	fmt.Fprintln(progress, "ℹ️  Keep 'gitops git-server serve' running so ArgoCD can reach them")
+	return nil
+}
+
+func (b *hostGitBackend) Connect() (func(), error) {
+	url := fmt.Sprintf("http://localhost:%s/", b.config.GitServerPort)
+	if err := waitForHTTP(url, 2*time.Second); err != nil {
+		return nil, fmt.Errorf("host git server is not running, start it with 'gitops git-server serve': %w", err)
+	}
+	return func() {}, nil
+}
+
+func (b *hostGitBackend) CommitMessage(name, revision string) (string, error) {
+	cmd := exec.Command("git", "--git-dir", filepath.Join(b.dataDir, name+".git"), "log", "-1", "--format=%s", revision)
+	output, err := runCommand(cmd, "git log "+name)
+	return strings.TrimSpace(string(output)), err
+}
+
+func (b *hostGitBackend) CreateRepo(name string) error {
+	return initBareRepository(b.dataDir, name)
+}
+
+func (b *hostGitBackend) ListRepos() ([]string, error) {
+	entries, err := os.ReadDir(b.dataDir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasSuffix(entry.Name(), ".git") {
+			names = append(names, strings.TrimSuffix(entry.Name(), ".git"))
+		}
+	}
+	return names, nil
+}
+
+func (b *hostGitBackend) DeleteRepo(name string) error {
+	return os.RemoveAll(filepath.Join(b.dataDir, name+".git"))
+}
+
+func (b *hostGitBackend) PushURL(name string) string {
+	return hostGitURL(b.config, name)
+}
+
+func (b *hostGitBackend) Credentials() (string, string, error) {
+	return gitServerCredentials(b.config, b.store)
+}
+
+func (b *hostGitBackend) PushCredentials() (string, string, error) {
+	return b.Credentials()
+}
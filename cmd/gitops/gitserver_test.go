@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestGitServerAuthManifest(t *testing.T) {
+	manifest := gitServerAuthManifest("git-server:1", "nginx:1", "devcluster")
+	for _, want := range []string{
+		"        app: git-server\n      annotations:\n        kubectl.kubernetes.io/default-container: git-server\n",
+		"              name: repo-storage\n        - name: auth-proxy\n          image: nginx:1\n",
+		"            claimName: git-server-pvc\n        - name: auth-proxy-config\n",
+		"            secretName: " + gitServerAuthSecretName + "\n",
+		"      targetPort: 8081\n",
+		"      listen 8081;\n",
+	} {
+		if !strings.Contains(manifest, want) {
+			t.Errorf("auth manifest lacks %q", want)
+		}
+	}
+	if strings.Contains(manifest, "targetPort: 8080") {
+		t.Error("auth manifest still targets the unauthenticated port")
+	}
+
+	plain := gitServerManifest("git-server:1", "devcluster", gitServerPod{targetPort: 8080})
+	if strings.Contains(plain, "auth-proxy") || !strings.Contains(plain, "      targetPort: 8080\n") {
+		t.Errorf("plain manifest is not the basic Git server:\n%s", plain)
+	}
+}
+
+func TestGiteaListReposPaging(t *testing.T) {
+	// The server caps pages at 30 items, below the requested limit
+	var all []string
+	for i := 0; i < 65; i++ {
+		all = append(all, fmt.Sprintf("repo-%02d", i))
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != giteaAdminUser || password != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		start, end := (page-1)*30, page*30
+		if start > len(all) {
+			start = len(all)
+		}
+		if end > len(all) {
+			end = len(all)
+		}
+		var items []string
+		for _, name := range all[start:end] {
+			items = append(items, `{"name":"`+name+`"}`)
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(items, ","))
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	store := &secretStore{stateDir: t.TempDir(), account: "test@0", mode: "file"}
+	if _, err := store.set(giteaAdminPasswordKey, "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	backend := &giteaGitBackend{config: &Config{GitServerPort: serverURL.Port()}, store: store}
+	names, err := backend.ListRepos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != len(all) || names[0] != all[0] || names[len(names)-1] != all[len(all)-1] {
+		t.Errorf("ListRepos returned %d repositories (%v), want %d", len(names), names, len(all))
+	}
+}
+
+func TestHtpasswdEntry(t *testing.T) {
+	entry, err := htpasswdEntry("gitops", "s3cret: #1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, hash, _ := strings.Cut(strings.TrimSuffix(entry, "\n"), ":")
+	if user != "gitops" || !strings.HasPrefix(hash, "$2y$") {
+		t.Fatalf("htpasswdEntry = %q, want a $2y$ bcrypt entry for gitops", entry)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte("s3cret: #1")); err != nil {
+		t.Errorf("hash does not match the password: %v", err)
+	}
+}
+
+func TestArgoCDRepositorySecretQuotesCredentials(t *testing.T) {
+	secret := argoCDRepositorySecret(&Config{}, "app", "gitops", `p: #"x"`)
+	if !strings.Contains(secret, "  username: \"gitops\"\n  password: \"p: #\\\"x\\\"\"\n") {
+		t.Errorf("credentials are not quoted:\n%s", secret)
+	}
+}
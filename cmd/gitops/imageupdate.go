@@ -0,0 +1,444 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var imageUpdateCmd = &cobra.Command{
+	Use:   "image-update",
+	Short: "Update image tags in manifests from the local registry",
+	Long: `Polls the local registry for new tags matching the image policies in .gitops-config.yaml,
+rewrites the image references in the manifest directory and commits the change to the Git server.
+
+Policies are declared one per line:
+  image_policy: myapp semver:^1.2
+  image_policy: worker latest
+  image_policy: api regex:^main-[0-9a-f]+$`,
+	RunE: runImageUpdate,
+}
+
+var (
+	imageUpdateTargetDir string
+	imageUpdateWatch     bool
+	imageUpdateInterval  time.Duration
+	imageUpdateDryRun    bool
+)
+
+func init() {
+	imageUpdateCmd.Flags().StringVar(&imageUpdateTargetDir, "target-dir", ".", "Target directory containing .gitops-config.yaml")
+	imageUpdateCmd.Flags().BoolVarP(&imageUpdateWatch, "watch", "w", false, "Keep polling the registry until interrupted")
+	imageUpdateCmd.Flags().DurationVar(&imageUpdateInterval, "interval", 30*time.Second, "Polling interval in watch mode")
+	imageUpdateCmd.Flags().BoolVar(&imageUpdateDryRun, "dry-run", false, "Show updates without changing manifests or pushing")
+}
+
+// ImagePolicy selects which registry tag an image should be updated to
+type ImagePolicy struct {
+	Image   string
+	Kind    string
+	Range   *semverConstraint
+	Pattern *regexp.Regexp
+}
+
+// String formats the policy as a config entry value
+func (p ImagePolicy) String() string {
+	policy := p.Kind
+	switch {
+	case p.Range != nil:
+		policy += ":" + p.Range.String()
+	case p.Pattern != nil:
+		policy += ":" + p.Pattern.String()
+	}
+	return p.Image + " " + policy
+}
+
+// MarshalJSON writes the policy in its config form
+func (p ImagePolicy) MarshalJSON() ([]byte, error) {
+	return marshalJSON(p.String(), "")
+}
+
+// imageUpdate describes a tag change applied to the manifests
+type imageUpdate struct {
+	Image  string
+	OldTag string
+	NewTag string
+}
+
+// manifestEdit is the new content of a manifest file, relative to the manifest directory
+type manifestEdit struct {
+	Path    string
+	Content string
+	Mode    os.FileMode
+}
+
+// imageRefPattern matches "image: <ref>" lines in YAML manifests
+var imageRefPattern = regexp.MustCompile(`^(\s*-?\s*image:\s*["']?)([^"'\s#]+)(["']?.*)$`)
+
+// parseImagePolicy parses "<image> semver:<range>|latest|regex:<pattern>"
+func parseImagePolicy(value string) (*ImagePolicy, error) {
+	fields := strings.Fields(value)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid image_policy %q: expected \"<image> <policy>\"", value)
+	}
+
+	policy := &ImagePolicy{Image: imageRepository(fields[0])}
+	spec := strings.Join(fields[1:], " ")
+	kind, arg, _ := strings.Cut(spec, ":")
+	policy.Kind = kind
+
+	switch kind {
+	case "semver":
+		if arg == "" {
+			return nil, fmt.Errorf("invalid image_policy for %s: semver needs a range, e.g. semver:^1.2", policy.Image)
+		}
+		constraint, err := parseSemverConstraint(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image_policy for %s: %w", policy.Image, err)
+		}
+		policy.Range = constraint
+	case "latest":
+	case "regex":
+		if arg == "" {
+			return nil, fmt.Errorf("invalid image_policy for %s: regex needs a pattern, e.g. regex:^main-", policy.Image)
+		}
+		pattern, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image_policy regex for %s: %w", policy.Image, err)
+		}
+		policy.Pattern = pattern
+	default:
+		return nil, fmt.Errorf("unknown image_policy %q for %s (use semver:<range>, latest or regex:<pattern>)", kind, policy.Image)
+	}
+
+	return policy, nil
+}
+
+// imageRepository strips the registry host and tag from an image reference
+func imageRepository(ref string) string {
+	name, _ := splitImageRef(ref)
+	if i := strings.Index(name, "/"); i >= 0 {
+		host := name[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			name = name[i+1:]
+		}
+	}
+	return name
+}
+
+// splitImageRef splits an image reference into name and tag
+func splitImageRef(ref string) (string, string) {
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+func runImageUpdate(cmd *cobra.Command, args []string) error {
+	// Read configuration
+	config, err := readConfig(imageUpdateTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if len(config.ImagePolicies) == 0 {
+		return fmt.Errorf("no image_policy entries found in %s", filepath.Join(imageUpdateTargetDir, ".gitops-config.yaml"))
+	}
+
+	if !imageUpdateWatch {
+		return updateImages(config, imageUpdateTargetDir)
+	}
+
+	fmt.Fprintf(progress, "👀 Watching registry %s:%s every %s (Ctrl+C to stop)\n", config.RegistryName, config.RegistryPort, imageUpdateInterval)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	ticker := time.NewTicker(imageUpdateInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := updateImages(config, imageUpdateTargetDir); err != nil {
+			fmt.Fprintf(progress, "⚠️  Image update failed: %v\n", err)
+		}
+
+		select {
+		case <-signals:
+			fmt.Fprintln(progress, "\n🛑 Stopping image updates")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// updateImages runs one polling cycle for all image policies
+func updateImages(config *Config, targetDir string) error {
+	fmt.Fprintln(progress, "🔍 Checking registry for image updates...")
+
+	registry := newRegistryClient(config)
+	manifestDir := filepath.Join(targetDir, "manifest")
+
+	var updates []imageUpdate
+	edits := map[string]*manifestEdit{}
+	for _, policy := range config.ImagePolicies {
+		tag, err := selectImageTag(registry, policy)
+		if err != nil {
+			return fmt.Errorf("failed to select tag for %s: %w", policy.Image, err)
+		}
+		if tag == "" {
+			if verbose {
+				fmt.Fprintf(progress, "ℹ️  No tag of %s matches its policy\n", policy.Image)
+			}
+			continue
+		}
+
+		changed, err := rewriteImageTags(manifestDir, config, policy.Image, tag, edits)
+		if err != nil {
+			return err
+		}
+		updates = append(updates, changed...)
+	}
+
+	if len(updates) == 0 {
+		fmt.Fprintln(progress, "✅ All images are up to date")
+		return nil
+	}
+
+	for _, update := range updates {
+		fmt.Fprintf(progress, "⬆️  %s: %s -> %s\n", update.Image, update.OldTag, update.NewTag)
+	}
+
+	if imageUpdateDryRun {
+		fmt.Fprintln(progress, "ℹ️  Dry run, manifests were not changed")
+		return nil
+	}
+
+	// The manifests on disk only change once the commit is pushed, so a failed push
+	// is retried on the next cycle
+	if err := commitManifestUpdate(config, targetDir, imageUpdateCommitMessage(updates), edits); err != nil {
+		return fmt.Errorf("failed to commit image updates: %w", err)
+	}
+	for _, edit := range edits {
+		path := filepath.Join(manifestDir, edit.Path)
+		if err := os.WriteFile(path, []byte(edit.Content), edit.Mode); err != nil {
+			return fmt.Errorf("failed to update %s: %w", path, err)
+		}
+	}
+
+	fmt.Fprintf(progress, "✅ Committed %d image update(s)\n", len(updates))
+	return nil
+}
+
+// selectImageTag returns the registry tag chosen by the policy, or "" if none matches
+func selectImageTag(registry *registryClient, policy ImagePolicy) (string, error) {
+	tags, err := registry.tags(policy.Image)
+	if err != nil {
+		return "", err
+	}
+
+	if policy.Kind == "semver" {
+		var best *semVersion
+		bestTag := ""
+		for _, tag := range tags {
+			v, ok := parseSemver(tag)
+			if !ok || !policy.Range.check(v) {
+				continue
+			}
+			if best == nil || v.compare(*best) > 0 {
+				best = &v
+				bestTag = tag
+			}
+		}
+		return bestTag, nil
+	}
+
+	// "latest" and "regex" pick the most recently built matching tag
+	type candidate struct {
+		tag     string
+		created time.Time
+	}
+	var candidates []candidate
+	for _, tag := range tags {
+		if policy.Pattern != nil && !policy.Pattern.MatchString(tag) {
+			continue
+		}
+		if policy.Kind == "latest" && tag == "latest" {
+			continue
+		}
+		created, err := registry.imageCreated(policy.Image, tag)
+		if err != nil {
+			return "", err
+		}
+		candidates = append(candidates, candidate{tag: tag, created: created})
+	}
+	if len(candidates) == 0 {
+		return "", nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].created.Equal(candidates[j].created) {
+			return candidates[i].tag > candidates[j].tag
+		}
+		return candidates[i].created.After(candidates[j].created)
+	})
+	return candidates[0].tag, nil
+}
+
+// rewriteImageTags points every reference to the local registry image at the new tag.
+// The rewritten files are collected in edits, keyed by their relative path, and files
+// already in edits are rewritten from their pending content.
+func rewriteImageTags(manifestDir string, config *Config, image, tag string, edits map[string]*manifestEdit) ([]imageUpdate, error) {
+	var updates []imageUpdate
+
+	err := filepath.Walk(manifestDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || (filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml") {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(manifestDir, path)
+		if err != nil {
+			return err
+		}
+		edit, pending := edits[relPath]
+		if !pending {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			edit = &manifestEdit{Path: relPath, Content: string(content), Mode: info.Mode()}
+		}
+
+		changed := false
+		lines := strings.Split(edit.Content, "\n")
+		for i, line := range lines {
+			m := imageRefPattern.FindStringSubmatch(line)
+			if m == nil || strings.Contains(m[2], "@") {
+				continue
+			}
+
+			name, oldTag := splitImageRef(m[2])
+			if imageRepository(name) != image || !isLocalRegistryImage(name, config) || oldTag == tag {
+				continue
+			}
+
+			lines[i] = m[1] + name + ":" + tag + m[3]
+			changed = true
+			updates = append(updates, imageUpdate{Image: name, OldTag: oldTag, NewTag: tag})
+		}
+
+		if changed {
+			edit.Content = strings.Join(lines, "\n")
+			edits[relPath] = edit
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan manifests in %s: %w", manifestDir, err)
+	}
+
+	return updates, nil
+}
+
+// isLocalRegistryImage reports whether an image name points at the local registry,
+// by its registry name (with or without k3d's "k3d-" prefix) or localhost
+func isLocalRegistryImage(name string, config *Config) bool {
+	host, _, found := strings.Cut(name, "/")
+	if !found {
+		return false
+	}
+	hostname, port, hasPort := strings.Cut(host, ":")
+	if hasPort && port != config.RegistryPort {
+		return false
+	}
+	switch hostname {
+	case config.RegistryName, "k3d-" + config.RegistryName:
+		return true
+	case "localhost":
+		return hasPort
+	}
+	return false
+}
+
+// imageUpdateCommitMessage names each image and its tag change
+func imageUpdateCommitMessage(updates []imageUpdate) string {
+	seen := map[string]bool{}
+	var lines []string
+	for _, update := range updates {
+		line := fmt.Sprintf("Update image %s from %s to %s", update.Image, update.OldTag, update.NewTag)
+		if !seen[line] {
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) == 1 {
+		return lines[0]
+	}
+	return fmt.Sprintf("Update %d images\n\n%s", len(lines), strings.Join(lines, "\n"))
+}
+
+// commitManifestUpdate commits the edited manifest files, and only those, on top of
+// the existing Git server history and pushes them
+func commitManifestUpdate(config *Config, targetDir, message string, edits map[string]*manifestEdit) error {
+	if err := setKubeconfig(config.ClusterName); err != nil {
+		return err
+	}
+
+	backend, err := newGitBackend(config, targetDir)
+	if err != nil {
+		return err
+	}
+	disconnect, err := backend.Connect()
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	// Stage the edited files in a directory laid out like the manifest directory
+	changesDir, err := os.MkdirTemp("", "gitops-changes-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(changesDir)
+	for _, edit := range edits {
+		path := filepath.Join(changesDir, "files", edit.Path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(edit.Content), 0644); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(changesDir, "message"), []byte(message+"\n"), 0644); err != nil {
+		return err
+	}
+
+	commitScript := fmt.Sprintf(`
+set -e
+git config --global user.email 'gitops@example.com'
+git config --global user.name 'GitOps CLI'
+git clone %s /tmp/workspace
+cd /tmp/workspace
+%s
+cp -r /changes/files/. /tmp/workspace/
+git add -A
+git commit -F /changes/message
+git push origin master
+`, shellQuote(backend.PushURL(manifestRepository)), gitCheckoutMaster)
+
+	if err := runGitContainer(config, backend, []string{changesDir + ":/changes:ro"}, commitScript); err != nil {
+		return err
+	}
+
+	notifyArgoCD(config, manifestRepository, "master")
+	return nil
+}
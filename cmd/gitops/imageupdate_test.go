@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseImagePolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		image   string
+		kind    string
+		policy  string
+		wantErr bool
+	}{
+		{value: "myapp semver:^1.2", image: "myapp", kind: "semver", policy: "^1.2"},
+		{value: "k3d-myregistry.localhost:5001/team/api:1.0.0 semver:>=1.0.0 <2.0.0", image: "team/api", kind: "semver", policy: ">=1.0.0 <2.0.0"},
+		{value: "worker latest", image: "worker", kind: "latest"},
+		{value: "api regex:^main-[0-9a-f]+$", image: "api", kind: "regex", policy: "^main-[0-9a-f]+$"},
+		{value: "myapp", wantErr: true},
+		{value: "myapp semver:", wantErr: true},
+		{value: "myapp semver:banana", wantErr: true},
+		{value: "myapp regex:", wantErr: true},
+		{value: "myapp regex:[", wantErr: true},
+		{value: "myapp newest", wantErr: true},
+	}
+	for _, tt := range tests {
+		policy, err := parseImagePolicy(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseImagePolicy(%q) succeeded, want an error", tt.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseImagePolicy(%q): %v", tt.value, err)
+			continue
+		}
+		if policy.Image != tt.image || policy.Kind != tt.kind {
+			t.Errorf("parseImagePolicy(%q) = %s %s, want %s %s", tt.value, policy.Image, policy.Kind, tt.image, tt.kind)
+		}
+		switch {
+		case policy.Range != nil && policy.Range.String() != tt.policy:
+			t.Errorf("parseImagePolicy(%q) range = %q, want %q", tt.value, policy.Range, tt.policy)
+		case policy.Pattern != nil && policy.Pattern.String() != tt.policy:
+			t.Errorf("parseImagePolicy(%q) pattern = %q, want %q", tt.value, policy.Pattern, tt.policy)
+		}
+	}
+}
+
+func TestImageRepository(t *testing.T) {
+	tests := map[string]string{
+		"myapp":                                 "myapp",
+		"myapp:1.0":                             "myapp",
+		"team/myapp:1.0":                        "team/myapp",
+		"k3d-myregistry.localhost:5001/myapp:2": "myapp",
+		"localhost:5001/team/myapp":             "team/myapp",
+	}
+	for ref, want := range tests {
+		if got := imageRepository(ref); got != want {
+			t.Errorf("imageRepository(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func TestIsLocalRegistryImage(t *testing.T) {
+	config := &Config{RegistryName: "myregistry.localhost", RegistryPort: "5001"}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"k3d-myregistry.localhost:5001/myapp", true},
+		{"myregistry.localhost:5001/myapp", true},
+		{"myregistry.localhost/myapp", true},
+		{"localhost:5001/myapp", true},
+		{"myregistry.localhost:5001x/myapp", false},
+		{"myregistry.localhost:5002/myapp", false},
+		{"evil-myregistry.localhost:5001/myapp", false},
+		{"localhost/myapp", false},
+		{"docker.io/library/nginx", false},
+		{"myapp", false},
+	}
+	for _, tt := range tests {
+		if got := isLocalRegistryImage(tt.name, config); got != tt.want {
+			t.Errorf("isLocalRegistryImage(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRewriteImageTags(t *testing.T) {
+	dir := t.TempDir()
+	manifest := `spec:
+  containers:
+    - image: k3d-myregistry.localhost:5001/myapp:1.0.0
+    - image: "k3d-myregistry.localhost:5001/worker:1.0.0" # pinned
+    - image: docker.io/myapp:1.0.0
+`
+	path := filepath.Join(dir, "deployment.yaml")
+	if err := os.WriteFile(path, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &Config{RegistryName: "myregistry.localhost", RegistryPort: "5001"}
+	edits := map[string]*manifestEdit{}
+	if _, err := rewriteImageTags(dir, config, "myapp", "1.1.0", edits); err != nil {
+		t.Fatal(err)
+	}
+	updates, err := rewriteImageTags(dir, config, "worker", "2.0.0", edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 1 || updates[0].OldTag != "1.0.0" || updates[0].NewTag != "2.0.0" {
+		t.Errorf("worker updates = %+v", updates)
+	}
+
+	want := `spec:
+  containers:
+    - image: k3d-myregistry.localhost:5001/myapp:1.1.0
+    - image: "k3d-myregistry.localhost:5001/worker:2.0.0" # pinned
+    - image: docker.io/myapp:1.0.0
+`
+	edit := edits["deployment.yaml"]
+	if edit == nil || edit.Content != want {
+		t.Errorf("edited content = %+v, want %q", edit, want)
+	}
+
+	// The file on disk only changes after the commit is pushed
+	content, _ := os.ReadFile(path)
+	if string(content) != manifest {
+		t.Errorf("rewriteImageTags changed %s on disk", path)
+	}
+}
@@ -20,6 +20,8 @@ var (
 	initArgoCDPort      string
 	initChartMuseumPort string
 	initGitServerPort   string
+	initGitBackend      string
+	initGitAuth         bool
 )
 
 func init() {
@@ -27,12 +29,19 @@ func init() {
 	initCmd.Flags().StringVar(&initArgoCDPort, "argocd-port", "8083", "ArgoCD server port")
 	initCmd.Flags().StringVar(&initChartMuseumPort, "chartmuseum-port", "8084", "ChartMuseum server port")
 	initCmd.Flags().StringVar(&initGitServerPort, "git-server-port", "8085", "Git server port")
+	initCmd.Flags().StringVar(&initGitBackend, "git-backend", gitBackendBasic, "Git server backend (basic, gitea, host)")
+	initCmd.Flags().BoolVar(&initGitAuth, "git-auth", false, "Require HTTP basic auth on the git server")
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
 	if targetDir == "" {
 		return fmt.Errorf("init directory is required")
 	}
+	switch initGitBackend {
+	case gitBackendBasic, gitBackendGitea, gitBackendHost:
+	default:
+		return fmt.Errorf("unknown git backend %q (use basic, gitea or host)", initGitBackend)
+	}
 
 	// Check if directory already exists
 	if _, err := os.Stat(targetDir); err == nil {
@@ -64,21 +73,27 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create config: %w", err)
 	}
 
-	fmt.Printf("✅ GitOps directory initialized successfully: %s\n", targetDir)
-	fmt.Println("")
-	fmt.Println("📋 Next steps:")
-	fmt.Printf("  1. cd %s\n", targetDir)
-	fmt.Println("  2. gitops setup")
-	fmt.Println("  3. gitops deploy")
-	fmt.Println("")
-	fmt.Println("📁 Directory structure created:")
-	fmt.Println("  ├── manifest/")
-	fmt.Println("  │   ├── deployment.yaml")
-	fmt.Println("  │   ├── service.yaml")
-	fmt.Println("  │   └── ingress.yaml")
-	fmt.Println("  ├── bootstrap.yaml")
-	fmt.Println("  ├── .gitops-config.yaml")
-	fmt.Println("  └── README.md")
+	// Keep the local state directory out of version control
+	if err := createGitignore(); err != nil {
+		return fmt.Errorf("failed to create .gitignore: %w", err)
+	}
+
+	fmt.Fprintf(progress, "✅ GitOps directory initialized successfully: %s\n", targetDir)
+	fmt.Fprintln(progress, "")
+	fmt.Fprintln(progress, "📋 Next steps:")
+	fmt.Fprintf(progress, "  1. cd %s\n", targetDir)
+	fmt.Fprintln(progress, "  2. gitops setup")
+	fmt.Fprintln(progress, "  3. gitops deploy")
+	fmt.Fprintln(progress, "")
+	fmt.Fprintln(progress, "📁 Directory structure created:")
+	fmt.Fprintln(progress, "  ├── manifest/")
+	fmt.Fprintln(progress, "  │   ├── deployment.yaml")
+	fmt.Fprintln(progress, "  │   ├── service.yaml")
+	fmt.Fprintln(progress, "  │   └── ingress.yaml")
+	fmt.Fprintln(progress, "  ├── bootstrap.yaml")
+	fmt.Fprintln(progress, "  ├── .gitops-config.yaml")
+	fmt.Fprintln(progress, "  ├── .gitignore")
+	fmt.Fprintln(progress, "  └── README.md")
 
 	return nil
 }
@@ -98,7 +113,8 @@ func createDirectoryStructure() error {
 }
 
 func createBootstrapYAML() error {
-	bootstrapYAML := `apiVersion: argoproj.io/v1alpha1
+	repoURL := gitRepoURL(&Config{GitServerBackend: initGitBackend, GitServerPort: initGitServerPort}, manifestRepository)
+	bootstrapYAML := fmt.Sprintf(`apiVersion: argoproj.io/v1alpha1
 kind: Application
 metadata:
   name: nginx-app
@@ -106,7 +122,7 @@ metadata:
 spec:
   project: default
   source:
-    repoURL: http://git-server.git-server.svc.cluster.local/manifest.git
+    repoURL: %s
     targetRevision: HEAD
     path: .
   destination:
@@ -118,7 +134,7 @@ spec:
       selfHeal: true
     syncOptions:
     - CreateNamespace=true
-`
+`, repoURL)
 
 	bootstrapPath := filepath.Join(targetDir, "bootstrap.yaml")
 	return os.WriteFile(bootstrapPath, []byte(bootstrapYAML), 0644)
@@ -249,8 +265,31 @@ registry_port: 5001
 argocd_port: %s
 chartmuseum_port: %s
 git_server_port: %s
-`, initClusterName, initArgoCDPort, initChartMuseumPort, initGitServerPort)
+# Git server backend: basic, gitea or host
+git_server_backend: %s
+git_server_auth: %t
+# Component versions (defaults are pinned by the CLI release)
+argocd_version: %s
+chartmuseum_version: %s
+git_server_version: %s
+git_image_version: %s
+# Cluster shape (see README: Cluster shape)
+cluster_servers: 1
+cluster_agents: 0
+cluster_port: 8080:80@loadbalancer
+cluster_port: 8443:443@loadbalancer
+`, initClusterName, initArgoCDPort, initChartMuseumPort, initGitServerPort, initGitBackend, initGitAuth,
+		defaultArgoCDVersion, defaultChartMuseumVersion, defaultGitServerVersion, defaultGitImageVersion)
 
 	configPath := filepath.Join(targetDir, ".gitops-config.yaml")
 	return os.WriteFile(configPath, []byte(configContent), 0644)
 }
+
+func createGitignore() error {
+	gitignoreContent := `# Local state and generated credentials
+.gitops/
+`
+
+	gitignorePath := filepath.Join(targetDir, ".gitignore")
+	return os.WriteFile(gitignorePath, []byte(gitignoreContent), 0644)
+}
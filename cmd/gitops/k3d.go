@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List local k3d environments and registries",
+	Long:  "Shows every k3d cluster with its state, nodes, host ports and connected registries, followed by the k3d registries",
+	Args:  cobra.NoArgs,
+	RunE:  runList,
+}
+
+// k3dPortBinding is a host port a container port is published on
+type k3dPortBinding struct {
+	HostIP   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+// k3dNode is a container k3d manages: a server, agent, load balancer or registry
+type k3dNode struct {
+	Name  string `json:"name"`
+	Role  string `json:"role"`
+	Image string `json:"image"`
+	State struct {
+		Running bool   `json:"Running"`
+		Status  string `json:"Status"`
+	} `json:"State"`
+	Ports    map[string][]k3dPortBinding `json:"portMappings"`
+	Networks []string                    `json:"networks"`
+}
+
+// hostPorts returns the published ports as "<host>-><container>", sorted
+func (n k3dNode) hostPorts() []string {
+	var ports []string
+	for containerPort, bindings := range n.Ports {
+		for _, binding := range bindings {
+			ports = append(ports, binding.HostPort+"->"+containerPort)
+		}
+	}
+	sort.Strings(ports)
+	return ports
+}
+
+// k3dCluster is a cluster as reported by `k3d cluster list -o json`
+type k3dCluster struct {
+	Name    string `json:"name"`
+	Network struct {
+		Name string `json:"name"`
+	} `json:"network"`
+	Nodes          []k3dNode `json:"nodes"`
+	ServersCount   int       `json:"serversCount"`
+	ServersRunning int       `json:"serversRunning"`
+	AgentsCount    int       `json:"agentsCount"`
+	AgentsRunning  int       `json:"agentsRunning"`
+}
+
+// running reports whether the cluster's API is up, i.e. a server is running
+func (c k3dCluster) running() bool {
+	return c.ServersRunning > 0
+}
+
+// state summarizes the cluster as running, stopped or degraded
+func (c k3dCluster) state() string {
+	switch {
+	case c.ServersRunning == 0:
+		return "stopped"
+	case c.ServersRunning < c.ServersCount || c.AgentsRunning < c.AgentsCount:
+		return "degraded"
+	default:
+		return "running"
+	}
+}
+
+// hostPorts returns the host ports published by the cluster's load balancer
+func (c k3dCluster) hostPorts() []string {
+	var ports []string
+	for _, node := range c.Nodes {
+		if node.Role == "loadbalancer" {
+			ports = append(ports, node.hostPorts()...)
+		}
+	}
+	return ports
+}
+
+// k3dInventory is the set of k3d clusters and registries on this Docker host
+type k3dInventory struct {
+	Clusters   []k3dCluster
+	Registries []k3dNode
+}
+
+// loadK3dInventory reads the clusters and registries from k3d's JSON output
+func loadK3dInventory() (*k3dInventory, error) {
+	inventory := &k3dInventory{}
+
+	output, err := runCommand(exec.Command("k3d", "cluster", "list", "-o", "json"), "k3d cluster list")
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(output, &inventory.Clusters); err != nil {
+		return nil, fmt.Errorf("failed to decode k3d clusters: %w", err)
+	}
+
+	output, err = runCommand(exec.Command("k3d", "registry", "list", "-o", "json"), "k3d registry list")
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(output, &inventory.Registries); err != nil {
+		return nil, fmt.Errorf("failed to decode k3d registries: %w", err)
+	}
+
+	sort.Slice(inventory.Clusters, func(i, j int) bool { return inventory.Clusters[i].Name < inventory.Clusters[j].Name })
+	sort.Slice(inventory.Registries, func(i, j int) bool { return inventory.Registries[i].Name < inventory.Registries[j].Name })
+	return inventory, nil
+}
+
+// cluster returns the cluster with exactly this name
+func (inv *k3dInventory) cluster(name string) (k3dCluster, bool) {
+	for _, cluster := range inv.Clusters {
+		if cluster.Name == name {
+			return cluster, true
+		}
+	}
+	return k3dCluster{}, false
+}
+
+// findK3dCluster looks up a cluster by its exact name, treating k3d errors as no cluster
+func findK3dCluster(name string) (k3dCluster, bool) {
+	inventory, err := loadK3dInventory()
+	if err != nil {
+		return k3dCluster{}, false
+	}
+	return inventory.cluster(name)
+}
+
+// registry returns the registry with this name; k3d prefixes container names with "k3d-"
+func (inv *k3dInventory) registry(name string) (k3dNode, bool) {
+	for _, registry := range inv.Registries {
+		if registry.Name == name || registry.Name == "k3d-"+name {
+			return registry, true
+		}
+	}
+	return k3dNode{}, false
+}
+
+// registryClusters returns the clusters whose network a registry is connected to
+func (inv *k3dInventory) registryClusters(registry k3dNode) []string {
+	var clusters []string
+	for _, cluster := range inv.Clusters {
+		for _, network := range registry.Networks {
+			if network == cluster.Network.Name {
+				clusters = append(clusters, cluster.Name)
+				break
+			}
+		}
+	}
+	return clusters
+}
+
+// clusterRegistries returns the registries connected to a cluster's network
+func (inv *k3dInventory) clusterRegistries(cluster k3dCluster) []string {
+	var registries []string
+	for _, registry := range inv.Registries {
+		for _, linked := range inv.registryClusters(registry) {
+			if linked == cluster.Name {
+				registries = append(registries, registry.Name)
+			}
+		}
+	}
+	return registries
+}
+
+// clusterEntry is a cluster row of gitops list
+type clusterEntry struct {
+	Name       string   `json:"name"`
+	State      string   `json:"state"`
+	Servers    string   `json:"servers"`
+	Agents     string   `json:"agents"`
+	Ports      []string `json:"ports"`
+	Registries []string `json:"registries"`
+}
+
+// registryEntry is a registry row of gitops list
+type registryEntry struct {
+	Name     string   `json:"name"`
+	State    string   `json:"state"`
+	Ports    []string `json:"ports"`
+	Clusters []string `json:"clusters"`
+}
+
+// environmentListing is the document list emits with --output json|yaml
+type environmentListing struct {
+	Clusters   []clusterEntry  `json:"clusters"`
+	Registries []registryEntry `json:"registries"`
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	inventory, err := loadK3dInventory()
+	if err != nil {
+		return fmt.Errorf("failed to read k3d inventory: %w", err)
+	}
+
+	listing := environmentListing{Clusters: []clusterEntry{}, Registries: []registryEntry{}}
+	for _, cluster := range inventory.Clusters {
+		listing.Clusters = append(listing.Clusters, clusterEntry{
+			Name:       cluster.Name,
+			State:      cluster.state(),
+			Servers:    fmt.Sprintf("%d/%d", cluster.ServersRunning, cluster.ServersCount),
+			Agents:     fmt.Sprintf("%d/%d", cluster.AgentsRunning, cluster.AgentsCount),
+			Ports:      cluster.hostPorts(),
+			Registries: inventory.clusterRegistries(cluster),
+		})
+	}
+	for _, registry := range inventory.Registries {
+		listing.Registries = append(listing.Registries, registryEntry{
+			Name:     registry.Name,
+			State:    registry.State.Status,
+			Ports:    registry.hostPorts(),
+			Clusters: inventory.registryClusters(registry),
+		})
+	}
+
+	if structuredOutputEnabled() {
+		return writeOutput(cmd.OutOrStdout(), listing)
+	}
+
+	out := cmd.OutOrStdout()
+	if len(listing.Clusters) == 0 {
+		fmt.Fprintln(out, "ℹ️  No k3d clusters found; create one with 'gitops setup'")
+	} else {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CLUSTER\tSTATE\tSERVERS\tAGENTS\tPORTS\tREGISTRIES")
+		for _, c := range listing.Clusters {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", c.Name, c.State, c.Servers, c.Agents,
+				listOrDash(c.Ports), listOrDash(c.Registries))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(listing.Registries) > 0 {
+		fmt.Fprintln(out, "")
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "REGISTRY\tSTATE\tPORTS\tCLUSTERS")
+		for _, r := range listing.Registries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Name, r.State, listOrDash(r.Ports), listOrDash(r.Clusters))
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+// listOrDash joins a list for a table cell, "-" when it is empty
+func listOrDash(items []string) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// loadTestClusters decodes a captured `k3d cluster list -o json`
+func loadTestClusters(t *testing.T) []k3dCluster {
+	data, err := os.ReadFile("testdata/k3d-cluster-list.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var clusters []k3dCluster
+	if err := json.Unmarshal(data, &clusters); err != nil {
+		t.Fatal(err)
+	}
+	return clusters
+}
+
+func TestK3dClusterDecoding(t *testing.T) {
+	clusters := loadTestClusters(t)
+	if len(clusters) != 2 {
+		t.Fatalf("decoded %d clusters, want 2", len(clusters))
+	}
+
+	tests := []struct {
+		cluster k3dCluster
+		name    string
+		state   string
+		running bool
+		ports   []string
+	}{
+		{clusters[0], "devcluster", "degraded", true, []string{"43187->6443/tcp", "8080->80/tcp", "8443->443/tcp"}},
+		{clusters[1], "other", "stopped", false, nil},
+	}
+	for _, tt := range tests {
+		if tt.cluster.Name != tt.name {
+			t.Errorf("cluster name = %q, want %q", tt.cluster.Name, tt.name)
+		}
+		if got := tt.cluster.state(); got != tt.state {
+			t.Errorf("%s state = %q, want %q", tt.name, got, tt.state)
+		}
+		if got := tt.cluster.running(); got != tt.running {
+			t.Errorf("%s running = %v, want %v", tt.name, got, tt.running)
+		}
+		if got := tt.cluster.hostPorts(); !reflect.DeepEqual(got, tt.ports) {
+			t.Errorf("%s host ports = %v, want %v", tt.name, got, tt.ports)
+		}
+	}
+
+	running := clusters[0]
+	running.AgentsRunning = running.AgentsCount
+	if got := running.state(); got != "running" {
+		t.Errorf("state with every node up = %q, want running", got)
+	}
+}
+
+func TestK3dInventoryRegistries(t *testing.T) {
+	inventory := &k3dInventory{
+		Clusters: loadTestClusters(t),
+		Registries: []k3dNode{
+			{Name: "k3d-myregistry.localhost", Role: "registry", Networks: []string{"bridge", "k3d-devcluster"}},
+			{Name: "k3d-docker-io-cache", Role: "registry", Networks: []string{"bridge"}},
+		},
+	}
+
+	if _, ok := inventory.registry("myregistry.localhost"); !ok {
+		t.Error("registry without the k3d- prefix not found")
+	}
+	if _, ok := inventory.cluster("dev"); ok {
+		t.Error("cluster lookup matched a name prefix")
+	}
+	if got := inventory.registryClusters(inventory.Registries[0]); !reflect.DeepEqual(got, []string{"devcluster"}) {
+		t.Errorf("registry clusters = %v", got)
+	}
+	if got := inventory.clusterRegistries(inventory.Clusters[0]); !reflect.DeepEqual(got, []string{"k3d-myregistry.localhost"}) {
+		t.Errorf("cluster registries = %v", got)
+	}
+	if got := inventory.clusterRegistries(inventory.Clusters[1]); len(got) != 0 {
+		t.Errorf("registries of an unconnected cluster = %v", got)
+	}
+}
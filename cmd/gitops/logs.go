@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs <application>",
+	Short: "Stream the logs of an ArgoCD Application's pods",
+	Long: `Finds the pods of an ArgoCD Application through the workloads in its resource tree and
+streams the logs of all their containers, each line prefixed with its pod and container.
+Pods created later, for example during a rollout, are attached automatically.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLogs,
+}
+
+var (
+	logsTargetDir string
+	logsSince     string
+	logsGrep      string
+	logsFollow    bool
+)
+
+func init() {
+	logsCmd.Flags().StringVar(&logsTargetDir, "target-dir", ".", "Target directory containing .gitops-config.yaml")
+	logsCmd.Flags().StringVar(&logsSince, "since", "", "Only show logs newer than a duration, e.g. 10m or 1h")
+	logsCmd.Flags().StringVar(&logsGrep, "grep", "", "Only show lines matching a regular expression")
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", true, "Keep streaming and attach to new pods")
+}
+
+// logsPollInterval is how often the pods of the Application are listed again
+const logsPollInterval = 2 * time.Second
+
+// logPrefixColors are the ANSI colors pod prefixes cycle through
+var logPrefixColors = []string{"36", "33", "32", "35", "34", "31"}
+
+// podContainer is a container whose logs can be read
+type podContainer struct {
+	Namespace string
+	Pod       string
+	Container string
+	// Running is false for containers that exited and only have their final logs
+	Running bool
+}
+
+func (c podContainer) key() string {
+	return c.Namespace + "/" + c.Pod + "/" + c.Container
+}
+
+// logStreamer runs one kubectl logs per container and serializes their output
+type logStreamer struct {
+	out    io.Writer
+	since  string
+	grep   *regexp.Regexp
+	follow bool
+	color  bool
+
+	mu     sync.Mutex
+	active map[string]bool
+	// ended records when a stream stopped so a reattach continues from there
+	ended  map[string]time.Time
+	colors map[string]string
+	wg     sync.WaitGroup
+}
+
+func runLogs(cmd *cobra.Command, args []string) error {
+	config, err := readConfig(logsTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if logsSince != "" {
+		if _, err := time.ParseDuration(logsSince); err != nil {
+			return fmt.Errorf("invalid --since %q: %w", logsSince, err)
+		}
+	}
+	streamer := &logStreamer{
+		out:    cmd.OutOrStdout(),
+		since:  logsSince,
+		follow: logsFollow,
+		color:  isTerminal(os.Stdout),
+		active: map[string]bool{},
+		ended:  map[string]time.Time{},
+		colors: map[string]string{},
+	}
+	if logsGrep != "" {
+		if streamer.grep, err = regexp.Compile(logsGrep); err != nil {
+			return fmt.Errorf("invalid --grep: %w", err)
+		}
+	}
+
+	if err := setKubeconfig(config.ClusterName); err != nil {
+		return fmt.Errorf("failed to set kubeconfig: %w", err)
+	}
+
+	app, err := findApplication(args[0])
+	if err != nil {
+		return err
+	}
+	workloads := workloadResources(app)
+	if len(workloads) == 0 {
+		return fmt.Errorf("application %s manages no workloads that run pods", app.Name)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	for {
+		containers, err := workloadContainers(workloads)
+		if err != nil {
+			return fmt.Errorf("failed to list pods of %s: %w", app.Name, err)
+		}
+		for _, container := range containers {
+			streamer.attach(container)
+		}
+
+		if !logsFollow {
+			if len(containers) == 0 {
+				fmt.Fprintf(progress, "ℹ️  %s has no running pods\n", app.Name)
+			}
+			streamer.wg.Wait()
+			return nil
+		}
+
+		select {
+		case <-signals:
+			return nil
+		case <-time.After(logsPollInterval):
+		}
+	}
+}
+
+// findApplication returns the status of an ArgoCD Application by name
+func findApplication(name string) (applicationStatus, error) {
+	apps, err := listArgoCDApplications()
+	if err != nil {
+		return applicationStatus{}, fmt.Errorf("failed to get ArgoCD applications: %w", err)
+	}
+	for _, app := range apps {
+		if app.Metadata.Name == name {
+			return newApplicationStatus(app), nil
+		}
+	}
+	return applicationStatus{}, fmt.Errorf("application %s not found (see gitops status)", name)
+}
+
+// workloadContainers resolves the workloads of an Application to the containers of
+// their pods that have started
+func workloadContainers(workloads []resourceStatus) ([]podContainer, error) {
+	var containers []podContainer
+	for _, workload := range workloads {
+		args := []string{"get", "pods", "-n", workload.Namespace, "-o", "json"}
+		if workload.Kind == "Pod" {
+			args = append(args, "--field-selector", "metadata.name="+workload.Name)
+		} else {
+			selector, err := workloadSelector(workload)
+			if err != nil {
+				return nil, err
+			}
+			if selector == "" {
+				continue
+			}
+			args = append(args, "-l", selector)
+		}
+
+		output, err := runCommand(exec.Command("kubectl", args...), "kubectl get pods of "+kubectlRef(workload))
+		if err != nil {
+			return nil, err
+		}
+		var list struct {
+			Items []struct {
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+				Status struct {
+					ContainerStatuses []struct {
+						Name  string `json:"name"`
+						State struct {
+							Running    *struct{} `json:"running"`
+							Terminated *struct{} `json:"terminated"`
+						} `json:"state"`
+					} `json:"containerStatuses"`
+				} `json:"status"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(output, &list); err != nil {
+			return nil, fmt.Errorf("failed to decode pods: %w", err)
+		}
+
+		for _, pod := range list.Items {
+			for _, status := range pod.Status.ContainerStatuses {
+				// Containers still being created have no logs yet
+				if status.State.Running != nil || status.State.Terminated != nil {
+					containers = append(containers, podContainer{
+						Namespace: workload.Namespace,
+						Pod:       pod.Metadata.Name,
+						Container: status.Name,
+						Running:   status.State.Running != nil,
+					})
+				}
+			}
+		}
+	}
+	return containers, nil
+}
+
+// workloadSelector returns the pod label selector of a workload as "key=value,...".
+// It is empty for a CronJob that has no Jobs yet.
+func workloadSelector(workload resourceStatus) (string, error) {
+	if workload.Kind == "batch/CronJob" {
+		cmd := exec.Command("kubectl", "get", "jobs", "-n", workload.Namespace, "-o", "json")
+		output, err := runCommand(cmd, "kubectl get jobs of "+kubectlRef(workload))
+		if err != nil {
+			return "", err
+		}
+		return cronJobPodSelector(output, workload.Name)
+	}
+
+	cmd := exec.Command("kubectl", "get", kubectlRef(workload), "-n", workload.Namespace, "-o", "jsonpath={.spec.selector.matchLabels}")
+	output, err := runCommand(cmd, "kubectl get selector of "+kubectlRef(workload))
+	if err != nil {
+		return "", err
+	}
+
+	var labels map[string]string
+	if err := json.Unmarshal(output, &labels); err != nil || len(labels) == 0 {
+		return "", fmt.Errorf("%s has no matchLabels selector", kubectlRef(workload))
+	}
+	var selector []string
+	for key, value := range labels {
+		selector = append(selector, key+"="+value)
+	}
+	sort.Strings(selector)
+	return strings.Join(selector, ","), nil
+}
+
+// cronJobPodSelector selects the pods of the Jobs a CronJob controls, given the
+// Jobs of its namespace as JSON. Job pods carry their Job's name in the job-name label.
+func cronJobPodSelector(jobs []byte, cronJob string) (string, error) {
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name            string `json:"name"`
+				OwnerReferences []struct {
+					Kind       string `json:"kind"`
+					Name       string `json:"name"`
+					Controller bool   `json:"controller"`
+				} `json:"ownerReferences"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(jobs, &list); err != nil {
+		return "", fmt.Errorf("failed to decode jobs: %w", err)
+	}
+
+	var names []string
+	for _, job := range list.Items {
+		for _, owner := range job.Metadata.OwnerReferences {
+			if owner.Controller && owner.Kind == "CronJob" && owner.Name == cronJob {
+				names = append(names, job.Metadata.Name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+	sort.Strings(names)
+	return "job-name in (" + strings.Join(names, ",") + ")", nil
+}
+
+// attach starts streaming a container unless it is already streamed
+func (s *logStreamer) attach(container podContainer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := container.key()
+	if s.active[key] {
+		return
+	}
+	// Without --follow, and for exited containers, the logs are read once
+	if _, seen := s.ended[key]; seen && (!s.follow || !container.Running) {
+		return
+	}
+	s.active[key] = true
+
+	args := []string{"logs", "pod/" + container.Pod, "-n", container.Namespace, "-c", container.Container}
+	if s.follow {
+		args = append(args, "--follow")
+	}
+	if endedAt, ok := s.ended[key]; ok {
+		// The container restarted or the stream dropped; skip what was already shown
+		args = append(args, "--since-time", endedAt.UTC().Format(time.RFC3339))
+	} else if s.since != "" {
+		args = append(args, "--since", s.since)
+	}
+
+	if _, ok := s.colors[container.Pod]; !ok {
+		s.colors[container.Pod] = logPrefixColors[len(s.colors)%len(logPrefixColors)]
+	}
+	prefix := fmt.Sprintf("[%s/%s] ", container.Pod, container.Container)
+	if s.color {
+		prefix = fmt.Sprintf("\x1b[%sm%s\x1b[0m", s.colors[container.Pod], prefix)
+	}
+
+	s.wg.Add(1)
+	go s.stream(key, prefix, exec.Command("kubectl", args...))
+}
+
+// stream copies the lines of one kubectl logs process to stdout
+func (s *logStreamer) stream(key, prefix string, cmd *exec.Cmd) {
+	defer s.wg.Done()
+
+	stdout, err := cmd.StdoutPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err == nil {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if s.grep != nil && !s.grep.MatchString(line) {
+				continue
+			}
+			s.mu.Lock()
+			fmt.Fprintln(s.out, prefix+line)
+			s.mu.Unlock()
+		}
+		cmd.Wait()
+	}
+
+	s.mu.Lock()
+	delete(s.active, key)
+	s.ended[key] = time.Now()
+	s.mu.Unlock()
+}
+
+// isTerminal reports whether a file is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestCronJobPodSelector(t *testing.T) {
+	jobs := []byte(`{"items": [
+		{"metadata": {"name": "report-2891", "ownerReferences": [{"kind": "CronJob", "name": "report", "controller": true}]}},
+		{"metadata": {"name": "report-2890", "ownerReferences": [{"kind": "CronJob", "name": "report", "controller": true}]}},
+		{"metadata": {"name": "cleanup-17", "ownerReferences": [{"kind": "CronJob", "name": "cleanup", "controller": true}]}},
+		{"metadata": {"name": "report-manual"}}
+	]}`)
+	tests := []struct {
+		cronJob string
+		want    string
+	}{
+		{"report", "job-name in (report-2890,report-2891)"},
+		{"cleanup", "job-name in (cleanup-17)"},
+		{"backup", ""},
+	}
+	for _, tt := range tests {
+		got, err := cronJobPodSelector(jobs, tt.cronJob)
+		if err != nil {
+			t.Fatalf("cronJobPodSelector(%q): %v", tt.cronJob, err)
+		}
+		if got != tt.want {
+			t.Errorf("cronJobPodSelector(%q) = %q, want %q", tt.cronJob, got, tt.want)
+		}
+	}
+	if _, err := cronJobPodSelector([]byte("not json"), "report"); err == nil {
+		t.Error("cronJobPodSelector accepted invalid JSON")
+	}
+}
@@ -21,6 +21,8 @@ var (
 		Short:   "Local GitOps Environment CLI",
 		Long:    "A CLI tool for managing a local GitOps environment with k3d, ArgoCD, ChartMuseum, and Git server",
 		Version: fmt.Sprintf("%s (commit: %s, built: %s)", version, commit, buildTime),
+		// Select the output format before any command prints
+		PersistentPreRunE: setupOutput,
 	}
 )
 
@@ -31,6 +33,7 @@ func main() {
 	rootCmd.PersistentFlags().StringVar(&registryName, "registry", "myregistry.localhost", "Docker registry name")
 	rootCmd.PersistentFlags().StringVar(&registryPort, "registry-port", "5001", "Docker registry port")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", outputText, "Output format: text, json or yaml (status, config view, registry ls, chart list, list, deploy)")
 	rootCmd.PersistentFlags().StringVar(&targetDir, "target-dir", "", "Initialize a new GitOps directory (fails if directory exists)")
 
 	// Add subcommands
@@ -40,6 +43,21 @@ func main() {
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(cleanupCmd)
 	rootCmd.AddCommand(portForwardCmd)
+	rootCmd.AddCommand(imageUpdateCmd)
+	rootCmd.AddCommand(registryCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(bundleCmd)
+	rootCmd.AddCommand(upgradeCmd)
+	rootCmd.AddCommand(argocdCmd)
+	rootCmd.AddCommand(gitServerCmd)
+	rootCmd.AddCommand(repoCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(chartCmd)
+	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(eventsCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(listCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var repoMirrorCmd = &cobra.Command{
+	Use:   "mirror <local-path>",
+	Short: "Push an existing local git repository to the git server",
+	Long: `Pushes all branches and tags (or only --branch) of a local git repository to the
+git server, keeping its history. With --sync the mirror is saved in .gitops-config.yaml
+and pushed again on every 'gitops deploy'. With --app an ArgoCD Application is created
+for --revision and --path of the mirrored repository.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRepoMirror,
+}
+
+var (
+	repoMirrorName         string
+	repoMirrorBranches     []string
+	repoMirrorSync         bool
+	repoMirrorApp          string
+	repoMirrorRevision     string
+	repoMirrorPath         string
+	repoMirrorAppNamespace string
+)
+
+func init() {
+	repoMirrorCmd.Flags().StringVar(&repoMirrorName, "name", "", "Repository name on the git server (default: directory name)")
+	repoMirrorCmd.Flags().StringSliceVar(&repoMirrorBranches, "branch", nil, "Branch to push (repeatable; default: all branches and tags)")
+	repoMirrorCmd.Flags().BoolVar(&repoMirrorSync, "sync", false, "Save the mirror in .gitops-config.yaml and push it on every deploy")
+	repoMirrorCmd.Flags().StringVar(&repoMirrorApp, "app", "", "Create an ArgoCD Application with this name")
+	repoMirrorCmd.Flags().StringVar(&repoMirrorRevision, "revision", "HEAD", "Branch, tag or commit the Application tracks")
+	repoMirrorCmd.Flags().StringVar(&repoMirrorPath, "path", ".", "Directory in the repository the Application deploys")
+	repoMirrorCmd.Flags().StringVar(&repoMirrorAppNamespace, "dest-namespace", "default", "Namespace the Application deploys to")
+
+	repoCmd.AddCommand(repoMirrorCmd)
+}
+
+// RepoMirror is a local repository pushed to the git server on every deploy
+type RepoMirror struct {
+	Name     string
+	Path     string
+	Branches []string
+}
+
+// parseRepoMirror parses a mirror config entry: "<name> <path> [<branch>,<branch>...]".
+// A path with spaces is written as a double-quoted Go string.
+func parseRepoMirror(value string) (*RepoMirror, error) {
+	fields, err := splitQuotedFields(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mirror %q: %w", value, err)
+	}
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil, fmt.Errorf("invalid mirror %q: expected \"<name> <path> [<branch>,...]\"", value)
+	}
+	if err := validateRepoName(fields[0]); err != nil {
+		return nil, err
+	}
+
+	mirror := &RepoMirror{Name: fields[0], Path: fields[1]}
+	if len(fields) == 3 {
+		mirror.Branches = strings.Split(fields[2], ",")
+	}
+	return mirror, nil
+}
+
+// splitQuotedFields splits a value on whitespace like strings.Fields, keeping
+// double-quoted fields such as "/my repos/app" together
+func splitQuotedFields(value string) ([]string, error) {
+	var fields []string
+	for rest := strings.TrimSpace(value); rest != ""; rest = strings.TrimSpace(rest) {
+		if rest[0] != '"' {
+			end := strings.IndexAny(rest, " \t")
+			if end < 0 {
+				end = len(rest)
+			}
+			fields = append(fields, rest[:end])
+			rest = rest[end:]
+			continue
+		}
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return nil, fmt.Errorf("unterminated quoted field %s", rest)
+		}
+		field, _ := strconv.Unquote(quoted)
+		fields = append(fields, field)
+		rest = rest[len(quoted):]
+	}
+	return fields, nil
+}
+
+// String formats the mirror as a config entry value
+func (m RepoMirror) String() string {
+	path := m.Path
+	if path == "" || strings.ContainsAny(path, " \t\"") {
+		path = strconv.Quote(path)
+	}
+	if len(m.Branches) == 0 {
+		return fmt.Sprintf("%s %s", m.Name, path)
+	}
+	return fmt.Sprintf("%s %s %s", m.Name, path, strings.Join(m.Branches, ","))
+}
+
+// MarshalJSON writes the mirror in its config form
+func (m RepoMirror) MarshalJSON() ([]byte, error) {
+	return marshalJSON(m.String(), "")
+}
+
+// saveRepoMirror adds or replaces the mirror entry with the same name in .gitops-config.yaml
+func saveRepoMirror(targetDir string, mirror RepoMirror) error {
+	configPath := filepath.Join(targetDir, ".gitops-config.yaml")
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(string(content), "\n"), "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if found && strings.TrimSpace(key) == "mirror" {
+			if fields := strings.Fields(value); len(fields) > 0 && fields[0] == mirror.Name {
+				continue
+			}
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "mirror: "+mirror.String())
+
+	return os.WriteFile(configPath, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// pushMirror pushes the refs of a local repository to the git server with the git container
+func pushMirror(config *Config, backend gitBackend, mirror RepoMirror) error {
+	refspecs := []string{shellQuote("+refs/heads/*:refs/heads/*"), shellQuote("+refs/tags/*:refs/tags/*")}
+	if len(mirror.Branches) > 0 {
+		refspecs = nil
+		for _, branch := range mirror.Branches {
+			refspecs = append(refspecs, shellQuote(fmt.Sprintf("+refs/heads/%[1]s:refs/heads/%[1]s", branch)))
+		}
+	}
+
+	// The repository is mounted read-only and owned by the host user
+	pushScript := fmt.Sprintf(`set -e
+git config --global --add safe.directory '*'
+git -C /source push --prune %s %s
+`, shellQuote(backend.PushURL(mirror.Name)), strings.Join(refspecs, " "))
+
+	if err := runGitContainer(config, backend, []string{mirror.Path + ":/source:ro"}, pushScript); err != nil {
+		return err
+	}
+
+	branches := mirror.Branches
+	if len(branches) == 0 {
+		branches = []string{currentBranch(mirror.Path)}
+	}
+	for _, branch := range branches {
+		notifyArgoCD(config, mirror.Name, branch)
+	}
+	return nil
+}
+
+// currentBranch returns the branch HEAD of a local repository points to, defaulting to master
+func currentBranch(repoPath string) string {
+	head, err := os.ReadFile(filepath.Join(repoPath, ".git", "HEAD"))
+	if err != nil {
+		if head, err = os.ReadFile(filepath.Join(repoPath, "HEAD")); err != nil {
+			return "master"
+		}
+	}
+	if branch, found := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: refs/heads/"); found {
+		return branch
+	}
+	return "master"
+}
+
+// syncRepoMirrors creates and pushes every mirror saved in the config
+func syncRepoMirrors(config *Config, targetDir string) error {
+	if len(config.Mirrors) == 0 {
+		return nil
+	}
+
+	backend, err := newGitBackend(config, targetDir)
+	if err != nil {
+		return err
+	}
+	disconnect, err := backend.Connect()
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	for _, mirror := range config.Mirrors {
+		if !filepath.IsAbs(mirror.Path) {
+			mirror.Path = filepath.Join(targetDir, mirror.Path)
+		}
+		if mirror.Path, err = filepath.Abs(mirror.Path); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(progress, "🪞 Syncing mirror %s from %s...\n", mirror.Name, mirror.Path)
+		if err := backend.CreateRepo(mirror.Name); err != nil {
+			return fmt.Errorf("failed to create repository %s: %w", mirror.Name, err)
+		}
+		if err := registerArgoCDRepository(config, backend, mirror.Name); err != nil {
+			return fmt.Errorf("failed to register repository %s with ArgoCD: %w", mirror.Name, err)
+		}
+		if err := pushMirror(config, backend, mirror); err != nil {
+			return fmt.Errorf("failed to push mirror %s: %w", mirror.Name, err)
+		}
+	}
+
+	fmt.Fprintln(progress, "✅ Mirrors synced")
+	return nil
+}
+
+// argoCDApplicationManifest renders an automatically syncing ArgoCD Application
+func argoCDApplicationManifest(name, project, repoURL, revision, path, namespace string) string {
+	return fmt.Sprintf(`apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: %s
+  namespace: argocd
+spec:
+  project: %s
+  source:
+    repoURL: %s
+    targetRevision: %s
+    path: %s
+  destination:
+    server: https://kubernetes.default.svc
+    namespace: %s
+  syncPolicy:
+    automated:
+      prune: true
+      selfHeal: true
+    syncOptions:
+    - CreateNamespace=true
+`, name, project, repoURL, revision, path, namespace)
+}
+
+func runRepoMirror(cmd *cobra.Command, args []string) error {
+	source, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(source, ".git")); err != nil {
+		if _, bareErr := os.Stat(filepath.Join(source, "HEAD")); bareErr != nil {
+			return fmt.Errorf("%s is not a git repository", source)
+		}
+	}
+
+	mirror := RepoMirror{Name: repoMirrorName, Path: source, Branches: repoMirrorBranches}
+	if mirror.Name == "" {
+		mirror.Name = strings.TrimSuffix(filepath.Base(source), ".git")
+	}
+	if err := validateRepoName(mirror.Name); err != nil {
+		return err
+	}
+
+	config, backend, disconnect, err := connectGitServer(repoTargetDir)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	fmt.Fprintf(progress, "🪞 Mirroring %s to repository %s...\n", source, mirror.Name)
+	if err := backend.CreateRepo(mirror.Name); err != nil {
+		return fmt.Errorf("failed to create repository %s: %w", mirror.Name, err)
+	}
+	if err := registerArgoCDRepository(config, backend, mirror.Name); err != nil {
+		return fmt.Errorf("failed to register repository %s with ArgoCD: %w", mirror.Name, err)
+	}
+	if err := pushMirror(config, backend, mirror); err != nil {
+		return fmt.Errorf("failed to push mirror %s: %w", mirror.Name, err)
+	}
+	fmt.Fprintf(progress, "✅ Mirrored %s (in-cluster URL: %s)\n", mirror.Name, gitRepoURL(config, mirror.Name))
+
+	if repoMirrorSync {
+		if err := saveRepoMirror(repoTargetDir, mirror); err != nil {
+			return fmt.Errorf("failed to save mirror: %w", err)
+		}
+		fmt.Fprintln(progress, "💾 Mirror saved; 'gitops deploy' keeps it in sync")
+	}
+
+	if repoMirrorApp != "" {
+		manifest := argoCDApplicationManifest(repoMirrorApp, applicationProject(config), gitRepoURL(config, mirror.Name), repoMirrorRevision, repoMirrorPath, repoMirrorAppNamespace)
+		applyCmd := exec.Command("kubectl", "apply", "-f", "-")
+		applyCmd.Stdin = strings.NewReader(manifest)
+		if _, err := runCommand(applyCmd, "kubectl apply Application "+repoMirrorApp); err != nil {
+			return fmt.Errorf("failed to create ArgoCD application %s: %w", repoMirrorApp, err)
+		}
+		fmt.Fprintf(progress, "✅ ArgoCD application %s tracks %s:%s at %s\n", repoMirrorApp, mirror.Name, repoMirrorRevision, repoMirrorPath)
+	}
+
+	return nil
+}
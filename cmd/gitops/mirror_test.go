@@ -0,0 +1,60 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRepoMirror(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    RepoMirror
+		wantErr bool
+	}{
+		{value: "app /src/app", want: RepoMirror{Name: "app", Path: "/src/app"}},
+		{value: "app\t/src/app  main,develop", want: RepoMirror{Name: "app", Path: "/src/app", Branches: []string{"main", "develop"}}},
+		{value: `app "/my repos/app" main`, want: RepoMirror{Name: "app", Path: "/my repos/app", Branches: []string{"main"}}},
+		{value: `app "/quoted \"name\""`, want: RepoMirror{Name: "app", Path: `/quoted "name"`}},
+		{value: "app", wantErr: true},
+		{value: `app "/unterminated`, wantErr: true},
+		{value: "app /src/app main extra", wantErr: true},
+		{value: "app.git /src/app", wantErr: true},
+	}
+	for _, tt := range tests {
+		mirror, err := parseRepoMirror(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseRepoMirror(%q) succeeded, want an error", tt.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseRepoMirror(%q): %v", tt.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(*mirror, tt.want) {
+			t.Errorf("parseRepoMirror(%q) = %+v, want %+v", tt.value, *mirror, tt.want)
+		}
+		// The config form parses back to the same mirror
+		again, err := parseRepoMirror(mirror.String())
+		if err != nil || !reflect.DeepEqual(again, mirror) {
+			t.Errorf("%q does not round-trip: %+v, %v", mirror.String(), again, err)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":                      `'plain'`,
+		"+refs/heads/*:refs/heads/*": `'+refs/heads/*:refs/heads/*'`,
+		"with space":                 `'with space'`,
+		"it's":                       `'it'\''s'`,
+		"$(rm -rf /)":                `'$(rm -rf /)'`,
+		"":                           `''`,
+	}
+	for input, want := range tests {
+		if got := shellQuote(input); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
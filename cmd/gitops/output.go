@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Output formats selectable with the global --output flag
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+var (
+	outputFormat string
+	// progress receives the messages commands print while they work. It is stderr
+	// while a structured format is selected so stdout only carries the document.
+	progress io.Writer = os.Stdout
+)
+
+// setupOutput validates --output and picks where progress messages go
+func setupOutput(cmd *cobra.Command, args []string) error {
+	switch outputFormat {
+	case outputText:
+		progress = cmd.OutOrStdout()
+		return nil
+	case outputJSON, outputYAML:
+		progress = cmd.ErrOrStderr()
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q (use text, json or yaml)", outputFormat)
+	}
+}
+
+// structuredOutputEnabled reports whether the command should emit a document instead of text
+func structuredOutputEnabled() bool {
+	return outputFormat == outputJSON || outputFormat == outputYAML
+}
+
+// writeOutput writes a document to w in the selected structured format. Field names
+// come from the json tags, so both formats share one schema.
+func writeOutput(w io.Writer, v interface{}) error {
+	data, err := marshalJSON(v, "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+
+	if outputFormat == outputYAML {
+		var out strings.Builder
+		if err := jsonToYAML(&out, data); err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		_, err = io.WriteString(w, out.String())
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// marshalJSON encodes v without escaping <, > and &, which appear in version ranges
+// and messages. The result ends with a newline.
+func marshalJSON(v interface{}, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", indent)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// jsonToYAML converts a JSON document to block-style YAML, keeping the key order
+func jsonToYAML(out *strings.Builder, data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decodeOrdered(decoder, &value); err != nil {
+		return err
+	}
+	writeYAMLValue(out, value, 0, false)
+	return nil
+}
+
+// yamlField is a key/value pair of a JSON object in document order
+type yamlField struct {
+	key   string
+	value interface{}
+}
+
+// decodeOrdered decodes the next JSON value, representing objects as []yamlField so
+// the YAML output follows the struct field order
+func decodeOrdered(decoder *json.Decoder, value *interface{}) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch delim := token.(type) {
+	case json.Delim:
+		switch delim {
+		case '{':
+			fields := []yamlField{}
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				var v interface{}
+				if err := decodeOrdered(decoder, &v); err != nil {
+					return err
+				}
+				fields = append(fields, yamlField{key: key.(string), value: v})
+			}
+			*value = fields
+		case '[':
+			items := []interface{}{}
+			for decoder.More() {
+				var v interface{}
+				if err := decodeOrdered(decoder, &v); err != nil {
+					return err
+				}
+				items = append(items, v)
+			}
+			*value = items
+		}
+		// Consume the closing delimiter
+		_, err = decoder.Token()
+		return err
+	default:
+		*value = token
+		return nil
+	}
+}
+
+// writeYAMLValue writes a decoded value at the given indentation. inline is set when
+// the value follows a "- " list marker and its first line needs no indentation.
+func writeYAMLValue(out *strings.Builder, value interface{}, indent int, inline bool) {
+	pad := strings.Repeat("  ", indent)
+	switch v := value.(type) {
+	case []yamlField:
+		if len(v) == 0 {
+			out.WriteString("{}\n")
+			return
+		}
+		for i, field := range v {
+			if i > 0 || !inline {
+				out.WriteString(pad)
+			}
+			out.WriteString(yamlScalar(field.key) + ":")
+			writeYAMLChild(out, field.value, indent)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			out.WriteString("[]\n")
+			return
+		}
+		for i, item := range v {
+			if i > 0 || !inline {
+				out.WriteString(pad)
+			}
+			out.WriteString("- ")
+			if isYAMLCollection(item) {
+				writeYAMLValue(out, item, indent+1, true)
+			} else {
+				out.WriteString(yamlScalar(item) + "\n")
+			}
+		}
+	default:
+		out.WriteString(yamlScalar(v) + "\n")
+	}
+}
+
+// writeYAMLChild writes the value of a mapping key: scalars and empty collections on
+// the same line, everything else indented below it
+func writeYAMLChild(out *strings.Builder, value interface{}, indent int) {
+	switch v := value.(type) {
+	case []yamlField:
+		if len(v) == 0 {
+			out.WriteString(" {}\n")
+			return
+		}
+		out.WriteString("\n")
+		writeYAMLValue(out, v, indent+1, false)
+	case []interface{}:
+		if len(v) == 0 {
+			out.WriteString(" []\n")
+			return
+		}
+		out.WriteString("\n")
+		writeYAMLValue(out, v, indent+1, false)
+	default:
+		out.WriteString(" " + yamlScalar(v) + "\n")
+	}
+}
+
+// isYAMLCollection reports whether a decoded value is a non-empty mapping or sequence
+func isYAMLCollection(value interface{}) bool {
+	switch v := value.(type) {
+	case []yamlField:
+		return len(v) > 0
+	case []interface{}:
+		return len(v) > 0
+	}
+	return false
+}
+
+// yamlScalar renders a scalar. Strings are quoted when YAML would otherwise read them
+// as another type or syntax; JSON escaping is valid in double-quoted YAML strings.
+func yamlScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return fmt.Sprint(v)
+	case json.Number:
+		return v.String()
+	case string:
+		if yamlNeedsQuotes(v) {
+			quoted, _ := marshalJSON(v, "")
+			return strings.TrimSuffix(string(quoted), "\n")
+		}
+		return v
+	case []yamlField:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// yamlNeedsQuotes reports whether a string cannot be written as a plain YAML scalar
+func yamlNeedsQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return true
+	}
+	// Numbers, dates and timestamps all start with a digit, a sign or a dot
+	if strings.ContainsAny(s[:1], "0123456789+-.?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+	return strings.HasSuffix(s, ":") || strings.Contains(s, ": ") || strings.Contains(s, " #") ||
+		strings.ContainsAny(s, "\n\r\t\\")
+}
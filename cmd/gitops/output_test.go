@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestYAMLScalarQuoting(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"plain", `plain`},
+		{"with space", `with space`},
+		{"a:b", `a:b`},
+		{"", `""`},
+		{" padded", `" padded"`},
+		{"yes", `"yes"`},
+		{"On", `"On"`},
+		{"null", `"null"`},
+		{"~", `"~"`},
+		{"0x1", `"0x1"`},
+		{"1.0", `"1.0"`},
+		{"-1", `"-1"`},
+		{".5", `".5"`},
+		{"*anchor", `"*anchor"`},
+		{"&anchor", `"&anchor"`},
+		{"!tag", `"!tag"`},
+		{"#comment", `"#comment"`},
+		{"value #comment", `"value #comment"`},
+		{"key: value", `"key: value"`},
+		{"trailing:", `"trailing:"`},
+		{"@at", `"@at"`},
+		{"`tick", "\"`tick\""},
+		{"two\nlines", `"two\nlines"`},
+		{`back\slash`, `"back\\slash"`},
+		{">=1.0 <2.0", `">=1.0 <2.0"`},
+	}
+	for _, tt := range tests {
+		if got := yamlScalar(tt.value); got != tt.want {
+			t.Errorf("yamlScalar(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestJSONToYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{
+			name: "scalars keep their types",
+			json: `{"name":"demo","count":3,"ratio":0.5,"enabled":true,"missing":null,"version":"1.0"}`,
+			want: "name: demo\ncount: 3\nratio: 0.5\nenabled: true\nmissing: null\nversion: \"1.0\"\n",
+		},
+		{
+			name: "empty collections",
+			json: `{"labels":{},"items":[],"nested":[{}]}`,
+			want: "labels: {}\nitems: []\nnested:\n  - {}\n",
+		},
+		{
+			name: "nested mappings and sequences keep their order",
+			json: `{"z":{"b":1,"a":["x","yes"]},"list":[{"k":"v","n":2},["inner"]]}`,
+			want: "z:\n  b: 1\n  a:\n    - x\n    - \"yes\"\nlist:\n  - k: v\n    \"n\": 2\n  - - inner\n",
+		},
+		{
+			name: "keys are quoted like values",
+			json: `{"on":1,"a: b":2}`,
+			want: "\"on\": 1\n\"a: b\": 2\n",
+		},
+		{
+			name: "multiline strings",
+			json: `{"message":"first\nsecond"}`,
+			want: "message: \"first\\nsecond\"\n",
+		},
+		{
+			name: "top level sequence",
+			json: `["a",{"b":"c"}]`,
+			want: "- a\n- b: c\n",
+		},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		if err := jsonToYAML(&out, []byte(tt.json)); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if out.String() != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, out.String(), tt.want)
+		}
+	}
+}
@@ -69,8 +69,8 @@ func portForwardAllServices(config *Config) error {
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	<-c
 
-	fmt.Println("\n🛑 Stopping port forwarding...")
-	fmt.Println("✅ Port forwarding stopped")
+	fmt.Fprintln(progress, "\n🛑 Stopping port forwarding...")
+	fmt.Fprintln(progress, "✅ Port forwarding stopped")
 
 	return nil
 }
@@ -89,11 +89,11 @@ func startPortForward(namespace, service, localPort, remotePort string) (*exec.C
 // printPortForwardInfo prints the port forward information for a service
 func printPortForwardInfo(serviceName, localPort, credentials string) {
 	if credentials != "" {
-		fmt.Printf("🌐 Starting %s port forward...\n", serviceName)
-		fmt.Printf("%s: http://localhost:%s (%s)\n", serviceName, localPort, credentials)
+		fmt.Fprintf(progress, "🌐 Starting %s port forward...\n", serviceName)
+		fmt.Fprintf(progress, "%s: http://localhost:%s (%s)\n", serviceName, localPort, credentials)
 	} else {
-		fmt.Printf("🌐 Starting %s port forward...\n", serviceName)
-		fmt.Printf("%s: http://localhost:%s\n", serviceName, localPort)
+		fmt.Fprintf(progress, "🌐 Starting %s port forward...\n", serviceName)
+		fmt.Fprintf(progress, "%s: http://localhost:%s\n", serviceName, localPort)
 	}
 }
 
@@ -111,7 +111,11 @@ func portForwardSpecificService(service string, config *Config) error {
 }
 
 func portForwardArgoCD(config *Config) error {
-	printPortForwardInfo("ArgoCD UI", config.ArgoCDPort, "credentials: admin/admin")
+	if !argoCDHasServer(config) {
+		fmt.Fprintln(progress, "ℹ️  The core ArgoCD install has no UI; manage Applications with kubectl")
+		return nil
+	}
+	printPortForwardInfo("ArgoCD UI", config.ArgoCDPort, "user: admin, password: gitops argocd password")
 
 	cmd, err := startPortForward("argocd", "svc/argocd-server", config.ArgoCDPort, "443")
 	if err != nil {
@@ -131,7 +135,19 @@ func portForwardChartMuseum(config *Config) error {
 }
 
 func portForwardGitServer(config *Config) error {
-	printPortForwardInfo("Git Server", config.GitServerPort, "")
+	switch config.GitServerBackend {
+	case gitBackendHost:
+		fmt.Fprintf(progress, "ℹ️  Git server runs on the host; start it with 'gitops git-server serve' (port %s)\n", config.GitServerPort)
+		return nil
+	case gitBackendGitea:
+		printPortForwardInfo("Git Server (Gitea)", config.GitServerPort, "user: "+giteaAdminUser+", password: gitops git-server credentials")
+	default:
+		credentials := ""
+		if config.GitServerAuth {
+			credentials = "user: " + gitServerUser + ", password: gitops git-server credentials"
+		}
+		printPortForwardInfo("Git Server", config.GitServerPort, credentials)
+	}
 
 	cmd, err := startPortForward("git-server", "svc/git-server", config.GitServerPort, "80")
 	if err != nil {
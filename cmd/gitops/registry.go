@@ -0,0 +1,297 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Manage images in the local Docker registry",
+	Long:  "Lists, deletes and garbage collects images in the local k3d Docker registry",
+}
+
+var registryLsCmd = &cobra.Command{
+	Use:   "ls [repository]",
+	Short: "List repositories and tags",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runRegistryLs,
+}
+
+var registryRmCmd = &cobra.Command{
+	Use:   "rm <image:tag>...",
+	Short: "Delete image manifests from the registry",
+	Long: `Deletes the manifests of the given tags. A manifest is shared by every tag of the same
+digest; when that includes tags not given, rm refuses unless --force is set.
+Run 'gitops registry gc' afterwards to free disk space.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRegistryRm,
+}
+
+var registryGcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Run garbage collection inside the registry container",
+	RunE:  runRegistryGc,
+}
+
+var registryDuCmd = &cobra.Command{
+	Use:   "du",
+	Short: "Show disk usage per repository",
+	RunE:  runRegistryDu,
+}
+
+var (
+	registryTargetDir string
+	registryRmForce   bool
+)
+
+func init() {
+	registryCmd.PersistentFlags().StringVar(&registryTargetDir, "target-dir", ".", "Target directory containing .gitops-config.yaml")
+	registryRmCmd.Flags().BoolVar(&registryRmForce, "force", false, "Also delete other tags of the same manifest")
+
+	registryCmd.AddCommand(registryLsCmd)
+	registryCmd.AddCommand(registryRmCmd)
+	registryCmd.AddCommand(registryGcCmd)
+	registryCmd.AddCommand(registryDuCmd)
+}
+
+// registryContainerName returns the Docker container k3d runs the registry in
+func registryContainerName(config *Config) string {
+	return "k3d-" + config.RegistryName
+}
+
+// registryRepository is a repository and its tags as listed by registry ls
+type registryRepository struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// registryListing is the document registry ls emits with --output json|yaml
+type registryListing struct {
+	Registry     string               `json:"registry"`
+	Repositories []registryRepository `json:"repositories"`
+}
+
+func runRegistryLs(cmd *cobra.Command, args []string) error {
+	config, err := readConfig(registryTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	registry := newRegistryClient(config)
+	repositories := args
+	if len(repositories) == 0 {
+		repositories, err = registry.catalog()
+		if err != nil {
+			return fmt.Errorf("failed to list repositories: %w", err)
+		}
+	}
+
+	listing := []registryRepository{}
+	for _, repository := range repositories {
+		tags, err := registry.tags(repository)
+		if err != nil {
+			return fmt.Errorf("failed to list tags of %s: %w", repository, err)
+		}
+		// Repositories whose tags were all removed list no tags rather than null
+		if tags == nil {
+			tags = []string{}
+		}
+		sort.Strings(tags)
+		listing = append(listing, registryRepository{Name: repository, Tags: tags})
+	}
+
+	if structuredOutputEnabled() {
+		return writeOutput(cmd.OutOrStdout(), registryListing{Registry: config.RegistryName + ":" + config.RegistryPort, Repositories: listing})
+	}
+
+	if len(listing) == 0 {
+		fmt.Fprintln(progress, "ℹ️  Registry is empty")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tTAGS")
+	for _, repository := range listing {
+		fmt.Fprintf(w, "%s\t%s\n", repository.Name, strings.Join(repository.Tags, ", "))
+	}
+	return w.Flush()
+}
+
+func runRegistryRm(cmd *cobra.Command, args []string) error {
+	config, err := readConfig(registryTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	// Resolve every tag first: the registry deletes manifests, so deleting one tag
+	// deletes all tags of the same digest
+	registry := newRegistryClient(config)
+	type manifestRef struct{ repository, digest string }
+	var deletes []manifestRef
+	tagsOf := map[manifestRef][]string{}
+	requested := map[string]bool{}
+	for _, ref := range args {
+		name, tag := splitImageRef(ref)
+		if tag == "" {
+			return fmt.Errorf("image %s has no tag, expected <image:tag>", ref)
+		}
+		repository := imageRepository(name)
+		requested[repository+":"+tag] = true
+
+		_, digest, err := registry.manifest(repository, tag)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", ref, err)
+		}
+		if digest == "" {
+			return fmt.Errorf("registry returned no digest for %s", ref)
+		}
+
+		key := manifestRef{repository, digest}
+		if _, ok := tagsOf[key]; ok {
+			continue
+		}
+		if tagsOf[key], err = registry.digestTags(repository, digest); err != nil {
+			return fmt.Errorf("failed to list tags of %s: %w", repository, err)
+		}
+		deletes = append(deletes, key)
+	}
+
+	if !registryRmForce {
+		for _, key := range deletes {
+			var siblings []string
+			for _, tag := range tagsOf[key] {
+				if !requested[key.repository+":"+tag] {
+					siblings = append(siblings, key.repository+":"+tag)
+				}
+			}
+			if len(siblings) > 0 {
+				return fmt.Errorf("%s (%s) is also tagged %s; deleting it removes those tags too, pass them as well or use --force",
+					key.repository, key.digest, strings.Join(siblings, ", "))
+			}
+		}
+	}
+
+	for _, key := range deletes {
+		if err := registry.deleteManifest(key.repository, key.digest); err != nil {
+			return fmt.Errorf("failed to delete %s@%s: %w", key.repository, key.digest, err)
+		}
+		for _, tag := range tagsOf[key] {
+			fmt.Fprintf(progress, "🗑️  Deleted %s:%s (%s)\n", key.repository, tag, key.digest)
+		}
+	}
+
+	fmt.Fprintln(progress, "ℹ️  Run `gitops registry gc` to reclaim disk space")
+	return nil
+}
+
+func runRegistryGc(cmd *cobra.Command, args []string) error {
+	config, err := readConfig(registryTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	fmt.Fprintln(progress, "🧹 Running registry garbage collection...")
+	gcCmd := exec.Command("docker", "exec", registryContainerName(config),
+		"registry", "garbage-collect", "--delete-untagged", "/etc/docker/registry/config.yml")
+	output, err := runCommand(gcCmd, "registry garbage-collect")
+	if err != nil {
+		return err
+	}
+
+	// Print only the summary line of the collector output
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) > 0 {
+		fmt.Fprintf(progress, "📋 %s\n", lines[len(lines)-1])
+	}
+
+	fmt.Fprintln(progress, "✅ Garbage collection completed")
+	return nil
+}
+
+func runRegistryDu(cmd *cobra.Command, args []string) error {
+	config, err := readConfig(registryTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	registry := newRegistryClient(config)
+	repositories, err := registry.catalog()
+	if err != nil {
+		return fmt.Errorf("failed to list repositories: %w", err)
+	}
+
+	type usage struct {
+		repository string
+		tags       int
+		size       int64
+	}
+	var usages []usage
+	var total int64
+	for _, repository := range repositories {
+		tags, err := registry.tags(repository)
+		if err != nil {
+			return fmt.Errorf("failed to list tags of %s: %w", repository, err)
+		}
+
+		// Layers shared between tags are only counted once
+		seen := map[string]bool{}
+		u := usage{repository: repository, tags: len(tags)}
+		for _, tag := range tags {
+			blobs, err := registry.blobs(repository, tag)
+			if err != nil {
+				return fmt.Errorf("failed to inspect %s:%s: %w", repository, tag, err)
+			}
+			for _, blob := range blobs {
+				if !seen[blob.Digest] {
+					seen[blob.Digest] = true
+					u.size += blob.Size
+				}
+			}
+		}
+		usages = append(usages, u)
+		total += u.size
+	}
+
+	sort.Slice(usages, func(i, j int) bool { return usages[i].size > usages[j].size })
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tTAGS\tSIZE")
+	for _, u := range usages {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", u.repository, u.tags, formatBytes(u.size))
+	}
+	fmt.Fprintf(w, "TOTAL\t\t%s\n", formatBytes(total))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// Report the real disk usage, which includes blobs not yet garbage collected
+	duCmd := exec.Command("docker", "exec", registryContainerName(config), "du", "-sh", "/var/lib/registry")
+	if output, err := runCommand(duCmd, "registry du"); err == nil {
+		fields := strings.Fields(string(output))
+		if len(fields) > 0 {
+			fmt.Fprintf(progress, "\n💾 On disk: %s\n", fields[0])
+		}
+	}
+
+	return nil
+}
+
+// formatBytes renders a byte count in human readable units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeRegistry serves the tags and manifests of one repository and records deletes
+func fakeRegistry(t *testing.T, tags map[string]string) (*httptest.Server, *[]string) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/myapp/tags/list":
+			var names []string
+			for tag := range tags {
+				names = append(names, `"`+tag+`"`)
+			}
+			w.Write([]byte(`{"name":"myapp","tags":[` + strings.Join(names, ",") + `]}`))
+		case strings.HasPrefix(r.URL.Path, "/v2/myapp/manifests/"):
+			reference := strings.TrimPrefix(r.URL.Path, "/v2/myapp/manifests/")
+			if r.Method == http.MethodDelete {
+				deleted = append(deleted, reference)
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			digest, ok := tags[reference]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", digest)
+			w.Write([]byte(`{"mediaType":"application/vnd.docker.distribution.manifest.v2+json"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &deleted
+}
+
+func TestRegistryRmSharedDigest(t *testing.T) {
+	server, deleted := fakeRegistry(t, map[string]string{
+		"1.0.0":  "sha256:aaa",
+		"latest": "sha256:aaa",
+		"0.9.0":  "sha256:bbb",
+	})
+	serverURL, _ := url.Parse(server.URL)
+
+	dir := t.TempDir()
+	config := "registry_port: " + serverURL.Port() + "\n"
+	if err := os.WriteFile(filepath.Join(dir, ".gitops-config.yaml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	registryTargetDir = dir
+	defer func() { registryTargetDir, registryRmForce = ".", false }()
+
+	tests := []struct {
+		args    []string
+		force   bool
+		wantErr string
+		deletes []string
+	}{
+		{args: []string{"myapp:1.0.0"}, wantErr: "myapp:latest"},
+		{args: []string{"myapp:1.0.0", "myapp:latest"}, deletes: []string{"sha256:aaa"}},
+		{args: []string{"myapp:1.0.0"}, force: true, deletes: []string{"sha256:aaa"}},
+		{args: []string{"myapp:0.9.0"}, deletes: []string{"sha256:bbb"}},
+		{args: []string{"myapp"}, wantErr: "has no tag"},
+	}
+	for _, tt := range tests {
+		*deleted = nil
+		registryRmForce = tt.force
+		err := runRegistryRm(registryRmCmd, tt.args)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("rm %v: error = %v, want one mentioning %q", tt.args, err, tt.wantErr)
+			}
+			if len(*deleted) > 0 {
+				t.Errorf("rm %v deleted %v despite the error", tt.args, *deleted)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("rm %v: %v", tt.args, err)
+			continue
+		}
+		if strings.Join(*deleted, ",") != strings.Join(tt.deletes, ",") {
+			t.Errorf("rm %v deleted %v, want %v", tt.args, *deleted, tt.deletes)
+		}
+	}
+}
+
+func TestRegistryLsPagesCatalog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/_catalog":
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", `</v2/_catalog?last=b&n=2>; rel="next"`)
+				w.Write([]byte(`{"repositories":["a","b"]}`))
+				return
+			}
+			w.Write([]byte(`{"repositories":["c"]}`))
+		case "/v2/a/tags/list", "/v2/c/tags/list":
+			w.Write([]byte(`{"tags":["1.0.0"]}`))
+		case "/v2/b/tags/list":
+			// Repositories whose tags were all removed report null
+			w.Write([]byte(`{"name":"b","tags":null}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	dir := t.TempDir()
+	config := "registry_name: registry\nregistry_port: " + serverURL.Port() + "\n"
+	if err := os.WriteFile(filepath.Join(dir, ".gitops-config.yaml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	registryTargetDir, outputFormat = dir, outputJSON
+	defer func() { registryTargetDir, outputFormat = ".", outputText }()
+
+	var out bytes.Buffer
+	registryLsCmd.SetOut(&out)
+	defer registryLsCmd.SetOut(nil)
+	if err := runRegistryLs(registryLsCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `"repositories": [
+    {
+      "name": "a",
+      "tags": [
+        "1.0.0"
+      ]
+    },
+    {
+      "name": "b",
+      "tags": []
+    },
+    {
+      "name": "c",`
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("registry ls output:\n%s\nwant it to contain\n%s", out.String(), want)
+	}
+}
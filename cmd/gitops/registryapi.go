@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Media types accepted when fetching manifests from the registry
+var registryManifestTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+}
+
+// registryClient talks to the local Docker registry through its v2 HTTP API
+type registryClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// registryDescriptor references a blob or manifest by digest
+type registryDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// registryManifest covers both single image manifests and manifest lists
+type registryManifest struct {
+	MediaType string               `json:"mediaType"`
+	Config    registryDescriptor   `json:"config"`
+	Layers    []registryDescriptor `json:"layers"`
+	Manifests []registryDescriptor `json:"manifests"`
+}
+
+func newRegistryClient(config *Config) *registryClient {
+	return &registryClient{
+		baseURL: fmt.Sprintf("http://localhost:%s", config.RegistryPort),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *registryClient) do(method, path string, accept []string) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(accept) > 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+
+	if verbose {
+		fmt.Fprintf(progress, "🔧 Registry API: %s %s\n", method, req.URL)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("registry request %s %s failed: %w", method, path, err)
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("registry request %s %s failed: %s %s", method, path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (c *registryClient) getJSON(path string, accept []string, out interface{}) (http.Header, error) {
+	resp, err := c.do(http.MethodGet, path, accept)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return nil, fmt.Errorf("failed to decode registry response for %s: %w", path, err)
+	}
+	return resp.Header, nil
+}
+
+// catalog lists all repositories in the registry
+func (c *registryClient) catalog() ([]string, error) {
+	var repositories []string
+	for path := "/v2/_catalog?n=1000"; path != ""; {
+		var result struct {
+			Repositories []string `json:"repositories"`
+		}
+		header, err := c.getJSON(path, nil, &result)
+		if err != nil {
+			return nil, err
+		}
+		repositories = append(repositories, result.Repositories...)
+		path = nextPagePath(header)
+	}
+	return repositories, nil
+}
+
+// tags lists all tags of a repository
+func (c *registryClient) tags(repository string) ([]string, error) {
+	var tags []string
+	for path := fmt.Sprintf("/v2/%s/tags/list?n=1000", repository); path != ""; {
+		var result struct {
+			Tags []string `json:"tags"`
+		}
+		header, err := c.getJSON(path, nil, &result)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, result.Tags...)
+		path = nextPagePath(header)
+	}
+	return tags, nil
+}
+
+// nextPagePath returns the path and query of the next page of a paginated
+// response, from its Link: <...>; rel="next" header, or "" on the last page
+func nextPagePath(header http.Header) string {
+	for _, link := range header.Values("Link") {
+		target, params, _ := strings.Cut(link, ";")
+		if !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		next, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err != nil {
+			return ""
+		}
+		return next.RequestURI()
+	}
+	return ""
+}
+
+// manifest fetches a manifest by tag or digest and returns it with its digest
+func (c *registryClient) manifest(repository, reference string) (*registryManifest, string, error) {
+	manifest := &registryManifest{}
+	header, err := c.getJSON(fmt.Sprintf("/v2/%s/manifests/%s", repository, reference), registryManifestTypes, manifest)
+	if err != nil {
+		return nil, "", err
+	}
+	return manifest, header.Get("Docker-Content-Digest"), nil
+}
+
+// digestTags returns the tags of a repository that point to a manifest digest
+func (c *registryClient) digestTags(repository, digest string) ([]string, error) {
+	tags, err := c.tags(repository)
+	if err != nil {
+		return nil, err
+	}
+	var matching []string
+	for _, tag := range tags {
+		_, tagDigest, err := c.manifest(repository, tag)
+		if err != nil {
+			return nil, err
+		}
+		if tagDigest == digest {
+			matching = append(matching, tag)
+		}
+	}
+	sort.Strings(matching)
+	return matching, nil
+}
+
+// imageManifest resolves manifest lists to the first platform image manifest
+func (c *registryClient) imageManifest(repository, reference string) (*registryManifest, error) {
+	manifest, _, err := c.manifest(repository, reference)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Manifests) > 0 {
+		manifest, _, err = c.manifest(repository, manifest.Manifests[0].Digest)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// imageCreated returns the build time recorded in the image config blob
+func (c *registryClient) imageCreated(repository, tag string) (time.Time, error) {
+	manifest, err := c.imageManifest(repository, tag)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var imageConfig struct {
+		Created time.Time `json:"created"`
+	}
+	if _, err := c.getJSON(fmt.Sprintf("/v2/%s/blobs/%s", repository, manifest.Config.Digest), nil, &imageConfig); err != nil {
+		return time.Time{}, err
+	}
+	return imageConfig.Created, nil
+}
+
+// deleteManifest removes a manifest by digest. The registry must have deletes enabled.
+func (c *registryClient) deleteManifest(repository, digest string) error {
+	resp, err := c.do(http.MethodDelete, fmt.Sprintf("/v2/%s/manifests/%s", repository, digest), nil)
+	if err != nil {
+		if strings.Contains(err.Error(), "405") {
+			return fmt.Errorf("%w\nthe registry does not allow deletes; recreate it with `gitops cleanup && gitops setup`", err)
+		}
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// blobs returns the config and layer blobs referenced by a tag, including all platforms
+func (c *registryClient) blobs(repository, tag string) ([]registryDescriptor, error) {
+	manifest, _, err := c.manifest(repository, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := []*registryManifest{manifest}
+	for _, child := range manifest.Manifests {
+		platformManifest, _, err := c.manifest(repository, child.Digest)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, platformManifest)
+	}
+
+	var blobs []registryDescriptor
+	for _, m := range manifests {
+		if m.Config.Digest != "" {
+			blobs = append(blobs, m.Config)
+		}
+		blobs = append(blobs, m.Layers...)
+	}
+	return blobs, nil
+}
+
+// parseImageReference splits an image into registry host, repository and tag,
+// applying the Docker Hub defaults
+func parseImageReference(image string) (string, string, string) {
+	name, tag := splitImageRef(image)
+	if tag == "" {
+		tag = "latest"
+	}
+
+	host := "registry-1.docker.io"
+	if i := strings.Index(name, "/"); i >= 0 && (strings.ContainsAny(name[:i], ".:") || name[:i] == "localhost") {
+		host, name = name[:i], name[i+1:]
+	}
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	if host == "registry-1.docker.io" && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	return host, name, tag
+}
+
+// resolveRemoteDigest looks up the manifest digest of an image tag in its upstream
+// registry, using an anonymous bearer token when the registry asks for one
+func resolveRemoteDigest(image string) (string, error) {
+	host, repository, tag := parseImageReference(image)
+	url := fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, repository, tag)
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	head := func(token string) (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodHead, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", strings.Join(registryManifestTypes, ", "))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return client.Do(req)
+	}
+
+	resp, err := head("")
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := fetchRegistryToken(client, resp.Header.Get("WWW-Authenticate"), repository)
+		if err != nil {
+			return "", err
+		}
+		if resp, err = head(token); err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HEAD %s: %s", url, resp.Status)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("HEAD %s returned no digest", url)
+	}
+	return digest, nil
+}
+
+// fetchRegistryToken answers a "Bearer realm=...,service=...,scope=..." challenge
+func fetchRegistryToken(client *http.Client, challenge, repository string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("unsupported registry authentication challenge: %q", challenge)
+	}
+
+	params := map[string]string{}
+	for _, part := range strings.Split(strings.TrimPrefix(challenge, "Bearer "), ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if found {
+			params[key] = strings.Trim(value, `"`)
+		}
+	}
+
+	if params["scope"] == "" {
+		params["scope"] = fmt.Sprintf("repository:%s:pull", repository)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, params["realm"], nil)
+	if err != nil {
+		return "", err
+	}
+	query := req.URL.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	req.URL.RawQuery = query.Encode()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request to %s failed: %s", params["realm"], resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode registry token: %w", err)
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	return token.AccessToken, nil
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var repoCmd = &cobra.Command{
+	Use:   "repo",
+	Short: "Manage repositories on the git server",
+	Long: `Creates, lists, deletes and clones repositories on the project's git server.
+Created repositories are registered with ArgoCD, so Applications can use them as sources.`,
+}
+
+var repoCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a repository and register it with ArgoCD",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRepoCreate,
+}
+
+var repoListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List repositories and their in-cluster URLs",
+	RunE:  runRepoList,
+}
+
+var repoDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a repository and its ArgoCD registration",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRepoDelete,
+}
+
+var repoCloneCmd = &cobra.Command{
+	Use:   "clone <name> [directory]",
+	Short: "Clone a repository to a local directory",
+	Long: `Clones a repository with the git container, so no local git is required. The clone's
+origin points to http://localhost:<git_server_port>, which is reachable while
+'gitops port-forward -s git-server' (or 'gitops git-server serve') is running.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runRepoClone,
+}
+
+var repoTargetDir string
+
+// repoNamePattern restricts repository names to what every git server backend accepts
+var repoNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func init() {
+	repoCmd.PersistentFlags().StringVar(&repoTargetDir, "target-dir", ".", "Target directory containing .gitops-config.yaml")
+
+	repoCmd.AddCommand(repoCreateCmd)
+	repoCmd.AddCommand(repoListCmd)
+	repoCmd.AddCommand(repoDeleteCmd)
+	repoCmd.AddCommand(repoCloneCmd)
+}
+
+// validateRepoName rejects names that would escape the repository root or clash with the .git suffix
+func validateRepoName(name string) error {
+	if !repoNamePattern.MatchString(name) || strings.HasSuffix(name, ".git") {
+		return fmt.Errorf("invalid repository name %q: use letters, digits, '.', '_' and '-' without a .git suffix", name)
+	}
+	return nil
+}
+
+// connectGitServer reads the project config and connects to its git server.
+// The returned function disconnects.
+func connectGitServer(targetDir string) (*Config, gitBackend, func(), error) {
+	config, err := readConfig(targetDir)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := setKubeconfig(config.ClusterName); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to set kubeconfig: %w", err)
+	}
+
+	backend, err := newGitBackend(config, targetDir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	disconnect, err := backend.Connect()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return config, backend, disconnect, nil
+}
+
+// argoCDRepositorySecretName returns the name of the ArgoCD repository Secret of a repository
+func argoCDRepositorySecretName(name string) string {
+	return "repo-" + strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+}
+
+// argoCDRepositorySecret renders the Secret that registers a repository with ArgoCD,
+// including the git server credentials when user is set
+func argoCDRepositorySecret(config *Config, name, user, password string) string {
+	secret := fmt.Sprintf(`apiVersion: v1
+kind: Secret
+metadata:
+  name: %s
+  namespace: argocd
+  labels:
+    argocd.argoproj.io/secret-type: repository
+    app.kubernetes.io/managed-by: gitops
+stringData:
+  type: git
+  name: %s
+  url: %s
+`, argoCDRepositorySecretName(name), name, gitRepoURL(config, name))
+	if user != "" {
+		secret += fmt.Sprintf("  username: %q\n  password: %q\n", user, password)
+	}
+	return secret
+}
+
+// registerArgoCDRepository creates or updates the ArgoCD repository Secret of a repository
+func registerArgoCDRepository(config *Config, backend gitBackend, name string) error {
+	user, password, err := backend.Credentials()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("kubectl", "apply", "-f", "-")
+	cmd.Stdin = strings.NewReader(argoCDRepositorySecret(config, name, user, password))
+	_, err = runCommand(cmd, "kubectl apply repository secret "+name)
+	return err
+}
+
+// unregisterArgoCDRepository removes the ArgoCD repository Secret of a repository
+func unregisterArgoCDRepository(name string) error {
+	cmd := exec.Command("kubectl", "delete", "secret", argoCDRepositorySecretName(name), "-n", "argocd", "--ignore-not-found")
+	_, err := runCommand(cmd, "kubectl delete repository secret "+name)
+	return err
+}
+
+func runRepoCreate(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if err := validateRepoName(name); err != nil {
+		return err
+	}
+
+	config, backend, disconnect, err := connectGitServer(repoTargetDir)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	fmt.Fprintf(progress, "📁 Creating repository %s...\n", name)
+	if err := backend.CreateRepo(name); err != nil {
+		return fmt.Errorf("failed to create repository %s: %w", name, err)
+	}
+	if err := registerArgoCDRepository(config, backend, name); err != nil {
+		return fmt.Errorf("failed to register repository %s with ArgoCD: %w", name, err)
+	}
+
+	fmt.Fprintf(progress, "✅ Repository %s created and registered with ArgoCD\n", name)
+	fmt.Fprintf(progress, "   In-cluster URL: %s\n", gitRepoURL(config, name))
+	return nil
+}
+
+func runRepoList(cmd *cobra.Command, args []string) error {
+	config, backend, disconnect, err := connectGitServer(repoTargetDir)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	names, err := backend.ListRepos()
+	if err != nil {
+		return fmt.Errorf("failed to list repositories: %w", err)
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(progress, "ℹ️  No repositories found")
+		return nil
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tURL")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\n", name, gitRepoURL(config, name))
+	}
+	return w.Flush()
+}
+
+func runRepoDelete(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if err := validateRepoName(name); err != nil {
+		return err
+	}
+	if name == manifestRepository {
+		fmt.Fprintf(progress, "⚠️  %s is the repository deploy pushes to; run 'gitops deploy' to recreate it\n", name)
+	}
+
+	_, backend, disconnect, err := connectGitServer(repoTargetDir)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	if err := backend.DeleteRepo(name); err != nil {
+		return fmt.Errorf("failed to delete repository %s: %w", name, err)
+	}
+	if err := unregisterArgoCDRepository(name); err != nil {
+		return fmt.Errorf("failed to unregister repository %s from ArgoCD: %w", name, err)
+	}
+
+	fmt.Fprintf(progress, "🗑️  Repository %s deleted\n", name)
+	return nil
+}
+
+func runRepoClone(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if err := validateRepoName(name); err != nil {
+		return err
+	}
+	directory := name
+	if len(args) == 2 {
+		directory = args[1]
+	}
+
+	destination, err := filepath.Abs(directory)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(destination); err == nil {
+		return fmt.Errorf("destination %s already exists", destination)
+	}
+	parent := filepath.Dir(destination)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", parent, err)
+	}
+
+	config, backend, disconnect, err := connectGitServer(repoTargetDir)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	// Clone in the git container, then point origin at the host port and hand the files to the user
+	localURL := fmt.Sprintf("http://localhost:%s%s", config.GitServerPort, gitRepoPath(config, name))
+	cloneScript := fmt.Sprintf(`set -e
+git clone --quiet %s %s
+git -C %[2]s remote set-url origin %[3]s
+chown -R %[4]d:%[5]d %[2]s
+`, shellQuote(backend.PushURL(name)), shellQuote("/work/"+filepath.Base(destination)), shellQuote(localURL), os.Getuid(), os.Getgid())
+
+	fmt.Fprintf(progress, "📥 Cloning %s into %s...\n", name, destination)
+	if err := runGitContainer(config, backend, []string{parent + ":/work"}, cloneScript); err != nil {
+		return fmt.Errorf("failed to clone repository %s: %w", name, err)
+	}
+
+	fmt.Fprintf(progress, "✅ Cloned %s (origin: %s)\n", name, localURL)
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// secretService is the keyring service name secrets are stored under
+const secretService = "local-gitops"
+
+// secretStore keeps generated credentials of a project, in the OS keyring when
+// available and otherwise in 0600 files under the project state directory
+type secretStore struct {
+	stateDir string
+	account  string
+	// legacyAccount is the account of releases that keyed secrets by cluster name only
+	legacyAccount string
+	mode          string
+}
+
+// newSecretStore returns the store for the project in targetDir
+func newSecretStore(config *Config, targetDir string) (*secretStore, error) {
+	stateDir, err := projectStateDir(targetDir)
+	if err != nil {
+		return nil, err
+	}
+	return &secretStore{
+		stateDir:      stateDir,
+		account:       secretAccount(config.ClusterName, stateDir),
+		legacyAccount: config.ClusterName,
+		mode:          config.SecretStore,
+	}, nil
+}
+
+// secretAccount names the keyring account of a project by its cluster name and a hash of
+// its state directory, so projects sharing a cluster name keep separate secrets
+func secretAccount(clusterName, stateDir string) string {
+	sum := sha256.Sum256([]byte(stateDir))
+	return clusterName + "@" + hex.EncodeToString(sum[:6])
+}
+
+// keyringAvailable reports whether a supported OS keyring CLI is installed
+func (s *secretStore) keyringAvailable() bool {
+	if s.mode == "file" {
+		return false
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		_, err := exec.LookPath("security")
+		return err == nil
+	case "linux":
+		_, err := exec.LookPath("secret-tool")
+		return err == nil
+	}
+	return false
+}
+
+// keyringAccount is the keyring account name for a secret
+func (s *secretStore) keyringAccount(key string) string {
+	return s.account + "/" + key
+}
+
+// keyringLookup reads a secret of an account from the OS keyring
+func keyringLookup(account string) (string, bool) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "find-generic-password", "-s", secretService, "-a", account, "-w")
+	} else {
+		cmd = exec.Command("secret-tool", "lookup", "service", secretService, "account", account)
+	}
+	output, err := cmd.Output()
+	if err != nil || len(output) == 0 {
+		return "", false
+	}
+	return strings.TrimSpace(string(output)), true
+}
+
+// get returns a stored secret, or "" if it does not exist
+func (s *secretStore) get(key string) (string, error) {
+	if s.keyringAvailable() {
+		if value, ok := keyringLookup(s.keyringAccount(key)); ok {
+			return value, nil
+		}
+		// Move a secret stored by an earlier release to the project's account
+		if value, ok := keyringLookup(s.legacyAccount + "/" + key); ok {
+			if _, err := s.set(key, value); err != nil {
+				return "", err
+			}
+			return value, nil
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(s.stateDir, key))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %w", key, err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// set stores a secret and returns where it was stored
+func (s *secretStore) set(key, value string) (string, error) {
+	if s.keyringAvailable() {
+		var cmd *exec.Cmd
+		if runtime.GOOS == "darwin" {
+			// security reads commands from stdin with -i, keeping the secret off its command line
+			cmd = exec.Command("security", "-i")
+			cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+				securityQuote(secretService), securityQuote(s.keyringAccount(key)), securityQuote(value)))
+		} else {
+			cmd = exec.Command("secret-tool", "store", "--label", fmt.Sprintf("%s %s", secretService, s.keyringAccount(key)),
+				"service", secretService, "account", s.keyringAccount(key))
+			cmd.Stdin = strings.NewReader(value)
+		}
+		if err := cmd.Run(); err == nil {
+			// Drop a stale file copy so the keyring stays authoritative
+			os.Remove(filepath.Join(s.stateDir, key))
+			return "OS keyring", nil
+		} else if s.mode == "keyring" {
+			return "", fmt.Errorf("failed to store secret %s in the OS keyring: %w", key, err)
+		}
+	}
+
+	path := filepath.Join(s.stateDir, key)
+	if err := os.WriteFile(path, []byte(value+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to write secret %s: %w", key, err)
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(path, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// securityQuote quotes an argument of a security -i command
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// generatePassword returns a random alphanumeric password
+func generatePassword(length int) (string, error) {
+	const alphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	password := make([]byte, length)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate password: %w", err)
+		}
+		password[i] = alphabet[n.Int64()]
+	}
+	return string(password), nil
+}
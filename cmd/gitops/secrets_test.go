@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretAccount(t *testing.T) {
+	a := secretAccount("devcluster", "/home/me/project-a/.gitops")
+	b := secretAccount("devcluster", "/home/me/project-b/.gitops")
+	if a == b {
+		t.Errorf("projects sharing a cluster name share the account %q", a)
+	}
+	if !strings.HasPrefix(a, "devcluster@") {
+		t.Errorf("secretAccount = %q, want the cluster name as prefix", a)
+	}
+	if again := secretAccount("devcluster", "/home/me/project-a/.gitops"); again != a {
+		t.Errorf("secretAccount is not stable: %q != %q", again, a)
+	}
+}
+
+func TestSecurityQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":       `"plain"`,
+		"with space":  `"with space"`,
+		`say "hi"`:    `"say \"hi\""`,
+		`back\slash`:  `"back\\slash"`,
+		`both\"mixed`: `"both\\\"mixed"`,
+	}
+	for input, want := range tests {
+		if got := securityQuote(input); got != want {
+			t.Errorf("securityQuote(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
+
+func TestSecretStoreFile(t *testing.T) {
+	dir := t.TempDir()
+	store := &secretStore{stateDir: dir, account: "test@0", legacyAccount: "test", mode: "file"}
+
+	if value, err := store.get("missing"); err != nil || value != "" {
+		t.Fatalf("get(missing) = %q, %v", value, err)
+	}
+	where, err := store.set("password", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if where != filepath.Join(dir, "password") {
+		t.Errorf("set stored in %q", where)
+	}
+	info, err := os.Stat(where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("secret file mode = %v, want 0600", info.Mode().Perm())
+	}
+	if value, err := store.get("password"); err != nil || value != "s3cret" {
+		t.Errorf("get(password) = %q, %v", value, err)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// semVersion is a parsed semantic version
+type semVersion struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Original   string
+}
+
+var semverPattern = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// findSemverPattern matches the first version number embedded in free text
+var findSemverPattern = regexp.MustCompile(`v?\d+\.\d+(?:\.\d+)?(?:-[0-9A-Za-z.-]+)?`)
+
+// parseSemver parses a version such as "1.2.3", "v1.2" or "1.2.3-rc.1"
+func parseSemver(s string) (semVersion, bool) {
+	m := semverPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return semVersion{}, false
+	}
+
+	v := semVersion{Prerelease: m[4], Original: s}
+	v.Major, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		v.Minor, _ = strconv.Atoi(m[2])
+	}
+	if m[3] != "" {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+	return v, true
+}
+
+// findSemver extracts the first version number from command output
+func findSemver(text string) (semVersion, bool) {
+	match := findSemverPattern.FindString(text)
+	if match == "" {
+		return semVersion{}, false
+	}
+	return parseSemver(match)
+}
+
+func (v semVersion) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// compare returns -1, 0 or 1 depending on whether v is lower, equal or higher than o
+func (v semVersion) compare(o semVersion) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+
+	// A release is higher than any of its prereleases
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	}
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+// comparePrerelease orders prerelease identifiers by SemVer precedence: numeric
+// identifiers compare numerically and rank below alphanumeric ones, and a shorter
+// list of otherwise equal identifiers ranks lower ("rc.9" < "rc.10" < "rc.10.1")
+func comparePrerelease(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return compareInts(an, bn)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		case as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return compareInts(len(as), len(bs))
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// semverComparator is a single "<op> <version>" term of a constraint
+type semverComparator struct {
+	op      string
+	version semVersion
+}
+
+func (c semverComparator) matches(v semVersion) bool {
+	cmp := v.compare(c.version)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// semverConstraint is a version range such as "^1.2", "~1.2.3", ">=1.0.0 <2.0.0" or "1.x".
+// Comma or space separated terms must all match; "||" separates alternatives.
+type semverConstraint struct {
+	raw          string
+	alternatives [][]semverComparator
+}
+
+func (c *semverConstraint) String() string {
+	return c.raw
+}
+
+// parseSemverConstraint parses a version range expression
+func parseSemverConstraint(s string) (*semverConstraint, error) {
+	constraint := &semverConstraint{raw: strings.TrimSpace(s)}
+
+	for _, alternative := range strings.Split(s, "||") {
+		var terms []semverComparator
+		fields := strings.FieldsFunc(alternative, func(r rune) bool { return r == ' ' || r == ',' })
+		// An empty range would match every version; "*" says so explicitly
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid version constraint %q: empty range", s)
+		}
+		for i := 0; i < len(fields); i++ {
+			field := fields[i]
+			// Allow a space between the operator and the version (">= 1.0")
+			if strings.Trim(field, "<>=~^") == "" && i+1 < len(fields) {
+				field += fields[i+1]
+				i++
+			}
+
+			expanded, err := expandSemverTerm(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version constraint %q: %w", s, err)
+			}
+			terms = append(terms, expanded...)
+		}
+		constraint.alternatives = append(constraint.alternatives, terms)
+	}
+
+	return constraint, nil
+}
+
+// expandSemverTerm turns a single term into plain comparators
+func expandSemverTerm(term string) ([]semverComparator, error) {
+	if term == "*" || term == "x" || term == "X" {
+		return nil, nil
+	}
+
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(term, prefix) {
+			op = prefix
+			term = strings.TrimPrefix(term, prefix)
+			break
+		}
+	}
+
+	// Count the explicitly given components so "1.2" and "1.x" behave like ranges
+	parts := strings.Split(strings.SplitN(strings.TrimPrefix(term, "v"), "-", 2)[0], ".")
+	precision := 0
+	for _, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		precision++
+	}
+	cleaned := strings.NewReplacer(".x", "", ".X", "", ".*", "").Replace(term)
+
+	v, ok := parseSemver(cleaned)
+	if !ok || precision == 0 {
+		return nil, fmt.Errorf("cannot parse version %q", term)
+	}
+
+	lower := semverComparator{op: ">=", version: v}
+	switch op {
+	case "^":
+		// The upper bound bumps the leftmost non-zero component: ^1.2.3 <2.0.0,
+		// ^0.2.3 <0.3.0 and ^0.0.3 <0.0.4
+		upper := semVersion{Major: v.Major + 1}
+		switch {
+		case v.Major == 0 && v.Minor == 0 && precision == 3:
+			upper = semVersion{Patch: v.Patch + 1}
+		case v.Major == 0 && precision > 1:
+			upper = semVersion{Minor: v.Minor + 1}
+		}
+		return []semverComparator{lower, {op: "<", version: upper}}, nil
+	case "~":
+		upper := semVersion{Major: v.Major, Minor: v.Minor + 1}
+		if precision == 1 {
+			upper = semVersion{Major: v.Major + 1}
+		}
+		return []semverComparator{lower, {op: "<", version: upper}}, nil
+	case "", "=":
+		if precision == 3 {
+			return []semverComparator{{op: "=", version: v}}, nil
+		}
+		upper := semVersion{Major: v.Major + 1}
+		if precision == 2 {
+			upper = semVersion{Major: v.Major, Minor: v.Minor + 1}
+		}
+		return []semverComparator{lower, {op: "<", version: upper}}, nil
+	default:
+		return []semverComparator{{op: op, version: v}}, nil
+	}
+}
+
+// check reports whether the version satisfies the constraint.
+// Prereleases only match when the constraint names a prerelease itself.
+func (c *semverConstraint) check(v semVersion) bool {
+	for _, terms := range c.alternatives {
+		matched := true
+		allowPrerelease := v.Prerelease == ""
+		for _, term := range terms {
+			if !term.matches(v) {
+				matched = false
+				break
+			}
+			if term.version.Prerelease != "" {
+				allowPrerelease = true
+			}
+		}
+		if matched && allowPrerelease {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package main
+
+import "testing"
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"1.2.3", "1.2.3", true},
+		{"v1.2", "1.2.0", true},
+		{"2", "2.0.0", true},
+		{"1.2.3-rc.1", "1.2.3-rc.1", true},
+		{"1.2.3+build.5", "1.2.3", true},
+		{"1.2.3-rc.1+build", "1.2.3-rc.1", true},
+		{"latest", "", false},
+		{"1.2.3.4", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		v, ok := parseSemver(tt.input)
+		if ok != tt.ok {
+			t.Errorf("parseSemver(%q) ok = %v, want %v", tt.input, ok, tt.ok)
+			continue
+		}
+		if ok && v.String() != tt.want {
+			t.Errorf("parseSemver(%q) = %s, want %s", tt.input, v, tt.want)
+		}
+	}
+}
+
+func TestFindSemver(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"k3d version v5.6.0\nk3s version v1.27.4-k3s1 (default)", "5.6.0"},
+		{"Client Version: v1.29.2\nKustomize Version: v5.0.4", "1.29.2"},
+		{"24.0.7", "24.0.7"},
+	}
+	for _, tt := range tests {
+		v, ok := findSemver(tt.output)
+		if !ok || v.String() != tt.want {
+			t.Errorf("findSemver(%q) = %s, %v, want %s", tt.output, v, ok, tt.want)
+		}
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0.0", "1.0.0-rc.1", 1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-rc.10", "1.0.0-rc.9", 1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+	}
+	for _, tt := range tests {
+		a, _ := parseSemver(tt.a)
+		b, _ := parseSemver(tt.b)
+		if got := a.compare(b); got != tt.want {
+			t.Errorf("compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := b.compare(a); got != -tt.want {
+			t.Errorf("compare(%s, %s) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestSemverConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"^1.2", "1.2.0", true},
+		{"^1.2", "1.9.9", true},
+		{"^1.2", "2.0.0", false},
+		{"^1.2", "1.1.9", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.3", true},
+		{"^0.0.3", "0.0.4", false},
+		{"^0.0", "0.0.9", true},
+		{"^0.0", "0.1.0", false},
+		{"^0", "0.9.0", true},
+		{"^0", "1.0.0", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"~1", "1.9.0", true},
+		{"~1", "2.0.0", false},
+		{"1.x", "1.5.0", true},
+		{"1.x", "2.0.0", false},
+		{"1.2", "1.2.7", true},
+		{"1.2", "1.3.0", false},
+		{"1.2.3", "1.2.3", true},
+		{"1.2.3", "1.2.4", false},
+		{">=1.0.0 <2.0.0", "1.5.0", true},
+		{">=1.0.0, <2.0.0", "2.0.0", false},
+		{">= 1.0", "1.0.0", true},
+		{"<1.0 || >=3.0", "0.9.0", true},
+		{"<1.0 || >=3.0", "2.0.0", false},
+		{"*", "9.9.9", true},
+		{"^1.2", "1.3.0-rc.1", false},
+		{">=1.3.0-rc.1", "1.3.0-rc.2", true},
+		{">=1.3.0-rc.9", "1.3.0-rc.10", true},
+		{">=20.10.0", "24.0.7", true},
+		{">=5.4.0 <6.0.0", "5.3.9", false},
+	}
+	for _, tt := range tests {
+		c, err := parseSemverConstraint(tt.constraint)
+		if err != nil {
+			t.Errorf("parseSemverConstraint(%q): %v", tt.constraint, err)
+			continue
+		}
+		v, ok := parseSemver(tt.version)
+		if !ok {
+			t.Fatalf("invalid test version %q", tt.version)
+		}
+		if got := c.check(v); got != tt.want {
+			t.Errorf("%q.check(%s) = %v, want %v", tt.constraint, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestParseSemverConstraintErrors(t *testing.T) {
+	for _, constraint := range []string{"", "  ", "1.0 ||", ">=", "^abc", "x.1"} {
+		if _, err := parseSemverConstraint(constraint); err == nil {
+			t.Errorf("parseSemverConstraint(%q) succeeded, want an error", constraint)
+		}
+	}
+}
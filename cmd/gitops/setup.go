@@ -2,12 +2,15 @@ package main
 
 import (
 	"encoding/base64"
-	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var setupCmd = &cobra.Command{
@@ -17,128 +20,256 @@ var setupCmd = &cobra.Command{
 	RunE:  runSetup,
 }
 
+var (
+	setupTargetDir string
+	setupWithCache bool
+	setupBundle    string
+)
+
+func init() {
+	setupCmd.Flags().StringVar(&setupTargetDir, "target-dir", ".", "Target directory containing .gitops-config.yaml")
+	setupCmd.Flags().BoolVar(&setupWithCache, "cache", false, "Create a persistent pull-through cache for docker.io, quay.io and ghcr.io")
+	setupCmd.Flags().StringVar(&setupBundle, "bundle", "", "Install from an offline bundle created by 'gitops bundle create'")
+}
+
 func runSetup(cmd *cobra.Command, args []string) error {
-	fmt.Println("🚀 Setting up Local GitOps Environment...")
+	fmt.Fprintln(progress, "🚀 Setting up Local GitOps Environment...")
 
 	// Read configuration
-	config, err := readConfig(".")
+	config, err := readConfig(setupTargetDir)
 	if err != nil {
 		return fmt.Errorf("failed to read config: %w", err)
 	}
 
 	if verbose {
-		fmt.Printf("📋 Using cluster name: %s\n", config.ClusterName)
+		fmt.Fprintf(progress, "📋 Using cluster name: %s\n", config.ClusterName)
 	}
 
 	// Check prerequisites
-	if err := checkPrerequisites(); err != nil {
+	if err := checkPrerequisites(config); err != nil {
 		return fmt.Errorf("prerequisites check failed: %w", err)
 	}
 
+	// Use vendored manifests and images when installing from a bundle
+	var manifests setupManifests
+	var bundle *offlineBundle
+	if setupBundle == "" {
+		if manifests, err = renderSetupManifests(config, config.PinDigests); err != nil {
+			return fmt.Errorf("failed to prepare component manifests: %w", err)
+		}
+	} else {
+		bundle, err = openBundle(setupBundle)
+		if err != nil {
+			return fmt.Errorf("failed to open bundle: %w", err)
+		}
+		defer bundle.Close()
+
+		if manifests, err = bundle.manifests(config); err != nil {
+			return fmt.Errorf("failed to read bundle manifests: %w", err)
+		}
+		if err := bundle.loadHostImages(); err != nil {
+			return fmt.Errorf("failed to load bundle images: %w", err)
+		}
+	}
+
 	// Create local registry
 	if err := createRegistry(); err != nil {
 		return fmt.Errorf("failed to create registry: %w", err)
 	}
 
+	// Mount the project's repository directory so repositories survive cluster recreation
+	gitDataDir, err := gitServerDataDir(setupTargetDir)
+	if err != nil {
+		return err
+	}
+	cluster := newK3dClusterConfig(config)
+	cluster.addVolume(fmt.Sprintf("%s:%s", gitDataDir, gitServerDataPath), "server:0")
+
+	// Create pull-through cache registries
+	if config.PullThroughCache || setupWithCache {
+		if err := createPullThroughCache(config); err != nil {
+			return fmt.Errorf("failed to create pull-through cache: %w", err)
+		}
+		if err := addPullThroughCache(config, cluster); err != nil {
+			return fmt.Errorf("failed to configure pull-through cache: %w", err)
+		}
+	}
+
 	// Create k3d cluster
-	if err := createCluster(config.ClusterName); err != nil {
+	if err := createCluster(cluster); err != nil {
 		return fmt.Errorf("failed to create cluster: %w", err)
 	}
 
+	if bundle != nil {
+		if err := bundle.importClusterImages(config.ClusterName); err != nil {
+			return fmt.Errorf("failed to import bundle images: %w", err)
+		}
+	}
+
+	// Reuse the stored admin password or generate a new one
+	store, err := newSecretStore(config, setupTargetDir)
+	if err != nil {
+		return err
+	}
+	adminPassword, err := argoCDAdminPassword(store)
+	if err != nil {
+		return fmt.Errorf("failed to prepare ArgoCD password: %w", err)
+	}
+
 	// Install ArgoCD
-	if err := installArgoCD(); err != nil {
+	if err := installArgoCD(config, manifests.ArgoCD, adminPassword); err != nil {
 		return fmt.Errorf("failed to install ArgoCD: %w", err)
 	}
+	if err := applyArgoCDProfile(config); err != nil {
+		return fmt.Errorf("failed to apply ArgoCD profile: %w", err)
+	}
 
 	// Install ChartMuseum
-	if err := installChartMuseum(); err != nil {
+	if err := installChartMuseum(manifests.ChartMuseum); err != nil {
 		return fmt.Errorf("failed to install ChartMuseum: %w", err)
 	}
 
 	// Install Git server
-	if err := setupGitServer(); err != nil {
+	backend, err := newGitBackend(config, setupTargetDir)
+	if err != nil {
+		return err
+	}
+	if err := backend.Install(manifests.GitServer); err != nil {
 		return fmt.Errorf("failed to install Git server: %w", err)
 	}
 
 	// Setup Git repository
-	if err := setupGitRepository(); err != nil {
+	if err := setupGitRepository(config, backend); err != nil {
 		return fmt.Errorf("failed to setup Git repository: %w", err)
 	}
 
 	// Print status
 	printStatus(config)
 
-	fmt.Println("✅ Local GitOps Environment setup completed!")
+	fmt.Fprintln(progress, "✅ Local GitOps Environment setup completed!")
 	return nil
 }
 
-func checkPrerequisites() error {
-	fmt.Println("🔍 Checking prerequisites...")
-
-	// Check if k3d is installed
-	if _, err := runCommand(exec.Command("k3d", "version"), "k3d version"); err != nil {
-		return fmt.Errorf("k3d is not installed or not in PATH")
-	}
-
-	// Check if kubectl is installed
-	if _, err := runCommand(exec.Command("kubectl", "version", "--client"), "kubectl version"); err != nil {
-		return fmt.Errorf("kubectl is not installed or not in PATH")
-	}
+// checkPrerequisites runs the critical doctor checks: tools, Docker resources and ports
+func checkPrerequisites(config *Config) error {
+	fmt.Fprintln(progress, "🔍 Checking prerequisites...")
 
-	// Check if docker is running
-	if _, err := runCommand(exec.Command("docker", "version"), "docker version"); err != nil {
-		return errors.New("docker is not running or not in PATH")
+	if err := runCriticalChecks(config); err != nil {
+		return err
 	}
 
-	fmt.Println("✅ Prerequisites check passed")
+	fmt.Fprintln(progress, "✅ Prerequisites check passed")
 	return nil
 }
 
 func createRegistry() error {
-	fmt.Println("🐳 Creating local Docker registry...")
+	fmt.Fprintln(progress, "🐳 Creating local Docker registry...")
 
 	// Check if registry already exists
-	cmd := exec.Command("k3d", "registry", "list")
-	output, err := runCommand(cmd, "k3d registry list")
+	inventory, err := loadK3dInventory()
 	if err != nil {
 		return err
 	}
 
-	if strings.Contains(string(output), registryName) {
-		fmt.Printf("ℹ️  Registry %s already exists\n", registryName)
+	if registry, ok := inventory.registry(registryName); ok {
+		if !registry.State.Running {
+			fmt.Fprintf(progress, "▶️  Starting stopped registry %s\n", registry.Name)
+			if _, err := runCommand(exec.Command("docker", "start", registry.Name), "docker start"); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(progress, "ℹ️  Registry %s already exists\n", registryName)
 		return nil
 	}
 
+	// Write a registry config with deletes enabled so `gitops registry rm` works
+	registryConfigPath, err := writeRegistryConfig()
+	if err != nil {
+		return err
+	}
+
 	// Create registry
-	cmd = exec.Command("k3d", "registry", "create", registryName, "--port", registryPort)
+	cmd := exec.Command("k3d", "registry", "create", registryName, "--port", registryPort,
+		"-v", fmt.Sprintf("%s:/etc/docker/registry/config.yml", registryConfigPath))
 	if _, err := runCommand(cmd, "k3d registry create"); err != nil {
 		return err
 	}
 
-	fmt.Printf("✅ Registry created at %s:%s\n", registryName, registryPort)
+	fmt.Fprintf(progress, "✅ Registry created at %s:%s\n", registryName, registryPort)
 	return nil
 }
 
-func createCluster(clusterName string) error {
-	fmt.Println("🏗️  Creating k3d cluster...")
+// writeRegistryConfig writes the distribution config mounted into the local registry
+func writeRegistryConfig() (string, error) {
+	homeDir, err := gitopsHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	registryConfig := `version: 0.1
+log:
+  fields:
+    service: registry
+storage:
+  cache:
+    blobdescriptor: inmemory
+  filesystem:
+    rootdirectory: /var/lib/registry
+  delete:
+    enabled: true
+http:
+  addr: :5000
+  headers:
+    X-Content-Type-Options: [nosniff]
+health:
+  storagedriver:
+    enabled: true
+    interval: 10s
+    threshold: 3
+`
+
+	configPath := filepath.Join(homeDir, "registry-config.yml")
+	if err := os.WriteFile(configPath, []byte(registryConfig), 0644); err != nil {
+		return "", fmt.Errorf("failed to write registry config: %w", err)
+	}
+	return configPath, nil
+}
+
+func createCluster(cluster *k3dClusterConfig) error {
+	fmt.Fprintln(progress, "🏗️  Creating k3d cluster...")
+	clusterName := cluster.Metadata.Name
 
 	// Check if cluster already exists
-	cmd := exec.Command("k3d", "cluster", "list")
-	output, err := runCommand(cmd, "k3d cluster list")
+	inventory, err := loadK3dInventory()
 	if err != nil {
 		return err
 	}
 
-	if strings.Contains(string(output), clusterName) {
-		fmt.Printf("ℹ️  Cluster %s already exists\n", clusterName)
+	if existing, ok := inventory.cluster(clusterName); ok {
+		if existing.state() != "running" {
+			fmt.Fprintf(progress, "▶️  Starting %s cluster %s\n", existing.state(), clusterName)
+			if _, err := runCommand(exec.Command("k3d", "cluster", "start", clusterName), "k3d cluster start"); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(progress, "ℹ️  Cluster %s already exists\n", clusterName)
+		// The shape only applies at creation, so changed settings need a new cluster
+		if drift := cluster.shapeDrift(existing); len(drift) > 0 {
+			fmt.Fprintf(progress, "⚠️  Cluster %s has %s; run 'gitops cleanup' and 'gitops setup' to recreate it with the configured shape\n",
+				clusterName, strings.Join(drift, ", "))
+		}
 		return nil
 	}
 
-	// Create cluster with registry
-	cmd = exec.Command("k3d", "cluster", "create", clusterName,
-		"--registry-use", fmt.Sprintf("k3d-%s:%s", registryName, registryPort),
-		"--port", "8080:80@loadbalancer",
-		"--port", "8443:443@loadbalancer")
+	// Create cluster from the rendered k3d config file
+	configPath, err := writeK3dClusterConfig(cluster)
+	if err != nil {
+		return err
+	}
+	if verbose {
+		fmt.Fprintf(progress, "📄 k3d config: %s\n", configPath)
+	}
+	cmd := exec.Command("k3d", "cluster", "create", "--config", configPath)
 	if _, err := runCommand(cmd, "k3d cluster create"); err != nil {
 		return err
 	}
@@ -148,89 +279,96 @@ func createCluster(clusterName string) error {
 		return err
 	}
 
-	fmt.Printf("✅ Cluster %s created successfully\n", clusterName)
+	fmt.Fprintf(progress, "✅ Cluster %s created successfully\n", clusterName)
 	return nil
 }
 
-func installArgoCD() error {
-	fmt.Println("🚀 Installing ArgoCD...")
+// installArgoCD applies the ArgoCD install manifest and, when the install has a
+// server, sets the admin password
+func installArgoCD(config *Config, manifest, adminPassword string) error {
+	fmt.Fprintln(progress, "🚀 Installing ArgoCD...")
 
 	// Create argocd namespace
 	cmd := exec.Command("kubectl", "create", "namespace", "argocd")
 	runCommand(cmd, "kubectl create namespace argocd") // Ignore error if namespace exists
 
 	// Install ArgoCD
-	cmd = exec.Command("kubectl", "apply", "-n", "argocd", "-f", "https://raw.githubusercontent.com/argoproj/argo-cd/stable/manifests/install.yaml")
+	cmd = exec.Command("kubectl", "apply", "-n", "argocd", "-f", "-")
+	cmd.Stdin = strings.NewReader(manifest)
 	if _, err := runCommand(cmd, "kubectl apply ArgoCD"); err != nil {
 		return err
 	}
 
 	// Wait for ArgoCD to be ready
-	fmt.Println("⏳ Waiting for ArgoCD to be ready...")
-	cmd = exec.Command("kubectl", "wait", "--for=condition=available", "--timeout=300s", "deployment/argocd-server", "-n", "argocd")
+	fmt.Fprintln(progress, "⏳ Waiting for ArgoCD to be ready...")
+	cmd = exec.Command("kubectl", "wait", "--for=condition=available", "--timeout=300s", "deployment", "--all", "-n", "argocd")
 	if _, err := runCommand(cmd, "kubectl wait ArgoCD"); err != nil {
 		return err
 	}
 
+	if config.ArgoCDInstall == argoCDInstallNamespace {
+		if err := applyArgoCDNamespaceAccess(config); err != nil {
+			return fmt.Errorf("failed to grant ArgoCD namespace access: %w", err)
+		}
+	}
+
 	// Configure ArgoCD password
-	if err := configureArgoCDPassword(); err != nil {
-		return fmt.Errorf("failed to configure ArgoCD password: %w", err)
+	if argoCDHasServer(config) {
+		if err := configureArgoCDPassword(adminPassword); err != nil {
+			return fmt.Errorf("failed to configure ArgoCD password: %w", err)
+		}
 	}
 
-	fmt.Println("✅ ArgoCD installed successfully")
+	fmt.Fprintln(progress, "✅ ArgoCD installed successfully")
 	return nil
 }
 
-func configureArgoCDPassword() error {
-	fmt.Println("🔐 Configuring ArgoCD password...")
+// configureArgoCDPassword sets the admin password to the bcrypt hash of password
+func configureArgoCDPassword(password string) error {
+	fmt.Fprintln(progress, "🔐 Configuring ArgoCD password...")
 
-	// Hardcoded bcrypt hash for password "admin"
-	// Generated with: htpasswd -nbB admin admin
-	adminPasswordHash := "$2y$05$JCbgj53Ew6p9TAaNUAk9cu15EB4yrjPp6yI3ucUl5MdmuiYM54.O2"
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
 
-	// Update password to 'admin' using the hardcoded hash
-	patchData := fmt.Sprintf(`{"stringData":{"admin.password":"%s"}}`, adminPasswordHash)
+	// Updating the mtime invalidates sessions issued for the previous password
+	patchData := fmt.Sprintf(`{"stringData":{"admin.password":"%s","admin.passwordMtime":"%s"}}`,
+		hash, time.Now().UTC().Format(time.RFC3339))
 	cmd := exec.Command("kubectl", "-n", "argocd", "patch", "secret", "argocd-secret", "-p", patchData)
 	if _, err := runCommand(cmd, "kubectl patch ArgoCD secret"); err != nil {
 		return err
 	}
 
+	// The generated initial password no longer applies
+	cmd = exec.Command("kubectl", "-n", "argocd", "delete", "secret", "argocd-initial-admin-secret", "--ignore-not-found")
+	runCommand(cmd, "kubectl delete ArgoCD initial admin secret")
+
 	// Verify password was set correctly
-	fmt.Println("🔍 Verifying ArgoCD password configuration...")
+	fmt.Fprintln(progress, "🔍 Verifying ArgoCD password configuration...")
 	cmd = exec.Command("kubectl", "-n", "argocd", "get", "secret", "argocd-secret", "-o", "jsonpath={.data.admin\\.password}")
 	output, err := runCommand(cmd, "kubectl get ArgoCD secret")
 	if err != nil {
 		return fmt.Errorf("failed to verify password: %w", err)
 	}
 
-	// Decode base64 and compare with expected hash
-	expectedHash := adminPasswordHash
-	actualHashBase64 := strings.TrimSpace(string(output))
-
 	// Decode the base64 value from the secret
-	actualHashBytes, err := base64.StdEncoding.DecodeString(actualHashBase64)
+	actualHash, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(output)))
 	if err != nil {
 		return fmt.Errorf("failed to decode base64 hash: %w", err)
 	}
-	actualHash := string(actualHashBytes)
 
-	if actualHash != expectedHash {
-		return fmt.Errorf("password verification failed: expected %s, got %s", expectedHash, actualHash)
+	if err := bcrypt.CompareHashAndPassword(actualHash, []byte(password)); err != nil {
+		return fmt.Errorf("password verification failed: %w", err)
 	}
 
-	fmt.Println("✅ ArgoCD password configured and verified (admin/admin)")
+	fmt.Fprintln(progress, "✅ ArgoCD password configured and verified")
 	return nil
 }
 
-func installChartMuseum() error {
-	fmt.Println("📦 Installing ChartMuseum...")
-
-	// Create chartmuseum namespace
-	cmd := exec.Command("kubectl", "create", "namespace", "chartmuseum")
-	runCommand(cmd, "kubectl create namespace chartmuseum") // Ignore error if namespace exists
-
-	// ChartMuseum deployment
-	chartmuseumYAML := `apiVersion: apps/v1
+// chartMuseumManifest deploys ChartMuseum with local chart storage
+func chartMuseumManifest(image string) string {
+	return fmt.Sprintf(`apiVersion: apps/v1
 kind: Deployment
 metadata:
   name: chartmuseum
@@ -247,7 +385,8 @@ spec:
     spec:
       containers:
         - name: chartmuseum
-          image: chartmuseum/chartmuseum:latest
+          image: %s
+          imagePullPolicy: IfNotPresent
           ports:
             - containerPort: 8080
           env:
@@ -274,30 +413,61 @@ spec:
     - port: 8080
       targetPort: 8080
   selector:
-    app: chartmuseum`
+    app: chartmuseum`, image)
+}
+
+func installChartMuseum(manifest string) error {
+	fmt.Fprintln(progress, "📦 Installing ChartMuseum...")
 
+	// Create chartmuseum namespace
+	cmd := exec.Command("kubectl", "create", "namespace", "chartmuseum")
+	runCommand(cmd, "kubectl create namespace chartmuseum") // Ignore error if namespace exists
+
+	// ChartMuseum deployment
 	cmd = exec.Command("kubectl", "apply", "-f", "-")
-	cmd.Stdin = strings.NewReader(chartmuseumYAML)
+	cmd.Stdin = strings.NewReader(manifest)
 	if _, err := runCommand(cmd, "kubectl apply ChartMuseum"); err != nil {
 		return err
 	}
 
 	// Wait for ChartMuseum to be ready
-	fmt.Println("⏳ Waiting for ChartMuseum to be ready...")
+	fmt.Fprintln(progress, "⏳ Waiting for ChartMuseum to be ready...")
 	cmd = exec.Command("kubectl", "wait", "--for=condition=available", "--timeout=300s", "deployment/chartmuseum", "-n", "chartmuseum")
 	if _, err := runCommand(cmd, "kubectl wait ChartMuseum"); err != nil {
 		return err
 	}
 
-	fmt.Println("✅ ChartMuseum installed successfully")
+	fmt.Fprintln(progress, "✅ ChartMuseum installed successfully")
 	return nil
 }
 
-func setupGitServer() error {
-	fmt.Println("📁 Installing Git server...")
+// gitServerDataPath is where the host repository directory is mounted inside the k3d server node
+const gitServerDataPath = "/var/lib/gitops/git-server"
 
-	// Git server deployment
-	gitServerYAML := `apiVersion: v1
+// k3dServerNode returns the node name of a k3d cluster's first server
+func k3dServerNode(clusterName string) string {
+	return fmt.Sprintf("k3d-%s-server-0", clusterName)
+}
+
+// gitServerDataDir returns the host directory that keeps the project's repositories
+// across cluster recreation
+func gitServerDataDir(targetDir string) (string, error) {
+	stateDir, err := projectStateDir(targetDir)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(stateDir, "git-data")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// gitServerStorageManifest creates the git-server namespace and a volume bound to the
+// host directory mounted into the cluster's server node
+func gitServerStorageManifest(clusterName string) string {
+	return fmt.Sprintf(`apiVersion: v1
 kind: Namespace
 metadata:
   name: git-server
@@ -311,8 +481,11 @@ spec:
     storage: 1Gi
   accessModes:
     - ReadWriteOnce
+  persistentVolumeReclaimPolicy: Retain
+  storageClassName: ""
   hostPath:
-    path: /data/git-server
+    path: %s
+    type: DirectoryOrCreate
   nodeAffinity:
     required:
       nodeSelectorTerms:
@@ -320,7 +493,7 @@ spec:
             - key: kubernetes.io/hostname
               operator: In
               values:
-                - k3d-local-gitops-server-0
+                - %s
 ---
 apiVersion: v1
 kind: PersistentVolumeClaim
@@ -330,9 +503,25 @@ metadata:
 spec:
   accessModes:
     - ReadWriteOnce
+  storageClassName: ""
+  volumeName: git-server-pv
   resources:
     requests:
-      storage: 1Gi
+      storage: 1Gi`, gitServerDataPath, k3dServerNode(clusterName))
+}
+
+// gitServerPod is what a variant of the Git server adds to its pod: pod template
+// annotations, sidecar containers and volumes, and the port its Service targets
+type gitServerPod struct {
+	annotations string
+	containers  string
+	volumes     string
+	targetPort  int
+}
+
+// gitServerManifest deploys the basic Git server on the persistent repository volume
+func gitServerManifest(image, clusterName string, pod gitServerPod) string {
+	return gitServerStorageManifest(clusterName) + fmt.Sprintf(`
 ---
 apiVersion: apps/v1
 kind: Deployment
@@ -347,20 +536,21 @@ spec:
   template:
     metadata:
       labels:
-        app: git-server
+        app: git-server%s
     spec:
       containers:
         - name: git-server
-          image: moikot/basic-git-server
+          image: %s
+          imagePullPolicy: IfNotPresent
           ports:
             - containerPort: 8080
           volumeMounts:
             - mountPath: /repos
-              name: repo-storage
+              name: repo-storage%s
       volumes:
         - name: repo-storage
           persistentVolumeClaim:
-            claimName: git-server-pvc
+            claimName: git-server-pvc%s
 ---
 apiVersion: v1
 kind: Service
@@ -370,49 +560,68 @@ metadata:
 spec:
   ports:
     - port: 80
-      targetPort: 8080
+      targetPort: %d
       name: http
   selector:
-    app: git-server`
+    app: git-server`, pod.annotations, image, pod.containers, pod.volumes, pod.targetPort)
+}
+
+func setupGitServer(manifest string) error {
+	fmt.Fprintln(progress, "📁 Installing Git server...")
 
+	// Git server deployment
 	cmd := exec.Command("kubectl", "apply", "-f", "-")
-	cmd.Stdin = strings.NewReader(gitServerYAML)
+	cmd.Stdin = strings.NewReader(manifest)
 	if _, err := runCommand(cmd, "kubectl apply Git server"); err != nil {
 		return err
 	}
 
 	// Wait for Git server to be ready
-	fmt.Println("⏳ Waiting for Git server to be ready...")
+	fmt.Fprintln(progress, "⏳ Waiting for Git server to be ready...")
 	cmd = exec.Command("kubectl", "wait", "--for=condition=available", "--timeout=300s", "deployment/git-server", "-n", "git-server")
 	if _, err := runCommand(cmd, "kubectl wait Git server"); err != nil {
 		return err
 	}
 
-	fmt.Println("✅ Git server installed successfully")
+	fmt.Fprintln(progress, "✅ Git server installed successfully")
 	return nil
 }
 
-func setupGitRepository() error {
-	fmt.Println("📁 Git repository setup...")
+func setupGitRepository(config *Config, backend gitBackend) error {
+	fmt.Fprintln(progress, "📁 Git repository setup...")
+
+	stop, err := backend.Connect()
+	if err != nil {
+		return err
+	}
+	defer stop()
 
-	// The moikot/basic-git-server automatically provides git server functionality
-	// No manual repository initialization needed
+	if err := backend.CreateRepo(manifestRepository); err != nil {
+		return fmt.Errorf("failed to create repository %s: %w", manifestRepository, err)
+	}
+	if err := registerArgoCDRepository(config, backend, manifestRepository); err != nil {
+		return fmt.Errorf("failed to register repository %s with ArgoCD: %w", manifestRepository, err)
+	}
 
-	fmt.Println("✅ Git repository setup completed")
+	fmt.Fprintln(progress, "✅ Git repository setup completed")
 	return nil
 }
 
 func printStatus(config *Config) error {
-	fmt.Println("")
-	fmt.Println("📊 Setup Status:")
-	fmt.Println("==================")
-	fmt.Printf("  Cluster: %s\n", config.ClusterName)
-	fmt.Printf("  Local Registry: %s:%s\n", config.RegistryName, config.RegistryPort)
-	fmt.Printf("  ArgoCD: http://localhost:%s (admin/admin)\n", config.ArgoCDPort)
-	fmt.Printf("  ChartMuseum: http://localhost:%s\n", config.ChartMuseumPort)
-	fmt.Printf("  Git Server: http://localhost:%s\n", config.GitServerPort)
-	fmt.Println("")
-	fmt.Println("🌐 To access services, run:")
-	fmt.Println("  gitops port-forward")
+	fmt.Fprintln(progress, "")
+	fmt.Fprintln(progress, "📊 Setup Status:")
+	fmt.Fprintln(progress, "==================")
+	fmt.Fprintf(progress, "  Cluster: %s\n", config.ClusterName)
+	fmt.Fprintf(progress, "  Local Registry: %s:%s\n", config.RegistryName, config.RegistryPort)
+	if argoCDHasServer(config) {
+		fmt.Fprintf(progress, "  ArgoCD (%s): http://localhost:%s (user: admin, password: gitops argocd password)\n", config.ArgoCDInstall, config.ArgoCDPort)
+	} else {
+		fmt.Fprintf(progress, "  ArgoCD (%s): no UI, manage Applications with kubectl\n", config.ArgoCDInstall)
+	}
+	fmt.Fprintf(progress, "  ChartMuseum: http://localhost:%s\n", config.ChartMuseumPort)
+	fmt.Fprintf(progress, "  Git Server (%s): http://localhost:%s\n", config.GitServerBackend, config.GitServerPort)
+	fmt.Fprintln(progress, "")
+	fmt.Fprintln(progress, "🌐 To access services, run:")
+	fmt.Fprintln(progress, "  gitops port-forward")
 	return nil
 }
@@ -1,30 +1,85 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 var (
 	statusTargetDir string
+	statusWatch     bool
 )
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show cluster and application status",
-	Long:  "Displays the status of the cluster, pods, ArgoCD applications, and example app",
-	RunE:  runStatus,
+	Long: `Displays the health of the local GitOps components and one row per ArgoCD Application
+with sync status, health, deployed revision and commit, and the last operation. The
+resources of unhealthy Applications are listed below the table.
+
+With --watch a full-screen view follows Applications, their resources, recent warning
+events and port forwards, with keys to sync, refresh, show logs or restart workloads.`,
+	RunE: runStatus,
 }
 
 func init() {
 	statusCmd.Flags().StringVar(&statusTargetDir, "target-dir", ".", "Target directory containing .gitops-config.yaml")
+	statusCmd.Flags().BoolVarP(&statusWatch, "watch", "w", false, "Show a live dashboard that updates as the cluster changes")
 }
 
-func runStatus(cmd *cobra.Command, args []string) error {
-	fmt.Println("📊 Cluster Status:")
+// componentStatus is the health of one part of the local GitOps environment
+type componentStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// resourceStatus is a resource an Application manages. Kind is qualified with the
+// API group, such as apps/Deployment, except for the core group.
+type resourceStatus struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Sync      string `json:"sync"`
+	Health    string `json:"health"`
+	Message   string `json:"message"`
+}
+
+// kindName returns the kind without its API group, as events and owner references name it
+func (r resourceStatus) kindName() string {
+	return r.Kind[strings.LastIndex(r.Kind, "/")+1:]
+}
 
+// applicationStatus is the state of an ArgoCD Application as shown by status
+type applicationStatus struct {
+	Name           string           `json:"name"`
+	Project        string           `json:"project"`
+	ApplicationSet string           `json:"applicationSet"`
+	Sync           string           `json:"sync"`
+	Health         string           `json:"health"`
+	Revision       string           `json:"revision"`
+	CommitMessage  string           `json:"commitMessage"`
+	LastOperation  string           `json:"lastOperation"`
+	Problems       []string         `json:"problems,omitempty"`
+	Resources      []resourceStatus `json:"resources,omitempty"`
+}
+
+// statusReport is the document status emits with --output json|yaml
+type statusReport struct {
+	Cluster      string              `json:"cluster"`
+	Components   []componentStatus   `json:"components"`
+	Applications []applicationStatus `json:"applications"`
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
 	// Read configuration
 	config, err := readConfig(statusTargetDir)
 	if err != nil {
@@ -32,7 +87,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	if verbose {
-		fmt.Printf("📋 Using cluster name: %s\n", config.ClusterName)
+		fmt.Fprintf(progress, "📋 Using cluster name: %s\n", config.ClusterName)
 	}
 
 	// Set kubeconfig
@@ -40,73 +95,254 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to set kubeconfig: %w", err)
 	}
 
-	// Show cluster status
-	if err := showClusterStatus(); err != nil {
-		return fmt.Errorf("failed to show cluster status: %w", err)
+	if statusWatch {
+		if structuredOutputEnabled() {
+			return fmt.Errorf("--watch only supports text output")
+		}
+		return runDashboard(config)
 	}
 
-	// Show pods status
-	if err := showPodsStatus(); err != nil {
-		return fmt.Errorf("failed to show pods status: %w", err)
+	components := collectComponentStatus(config)
+	apps, err := collectApplicationStatus(config, statusTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to get ArgoCD applications: %w", err)
 	}
 
-	// Show ArgoCD applications
-	if err := showArgoCDApplications(); err != nil {
-		return fmt.Errorf("failed to show ArgoCD applications: %w", err)
+	if structuredOutputEnabled() {
+		if apps == nil {
+			apps = []applicationStatus{}
+		}
+		return writeOutput(cmd.OutOrStdout(), statusReport{Cluster: config.ClusterName, Components: components, Applications: apps})
 	}
 
-	// Show example app status
-	if err := showExampleAppStatus(); err != nil {
-		return fmt.Errorf("failed to show example app status: %w", err)
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "📊 Components (cluster %s):\n", config.ClusterName)
+	if err := printComponentStatus(out, components); err != nil {
+		return err
 	}
 
-	return nil
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "📊 ArgoCD Applications:")
+	if len(apps) == 0 {
+		fmt.Fprintln(out, "No ArgoCD applications found")
+		return nil
+	}
+	return printApplicationStatus(out, apps)
 }
 
-func showClusterStatus() error {
-	cmd := exec.Command("kubectl", "get", "nodes")
-	output, err := runCommand(cmd, "kubectl get nodes")
+// workloadStatus summarizes the readiness of the deployments and statefulsets in a
+// namespace, optionally limited to one workload name
+func workloadStatus(namespace, name string) componentStatus {
+	cmd := exec.Command("kubectl", "get", "deployments,statefulsets", "-n", namespace, "-o", "json")
+	output, err := runCommand(cmd, "kubectl get workloads -n "+namespace)
 	if err != nil {
-		return fmt.Errorf("failed to get nodes: %w", err)
+		return componentStatus{Status: "Unknown", Detail: "cluster not reachable"}
+	}
+
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				Replicas *int `json:"replicas"`
+			} `json:"spec"`
+			Status struct {
+				ReadyReplicas int `json:"readyReplicas"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(output, &list); err != nil {
+		return componentStatus{Status: "Unknown", Detail: err.Error()}
+	}
+
+	var notReady []string
+	total := 0
+	for _, item := range list.Items {
+		if name != "" && item.Metadata.Name != name {
+			continue
+		}
+		total++
+		want := 1
+		if item.Spec.Replicas != nil {
+			want = *item.Spec.Replicas
+		}
+		if item.Status.ReadyReplicas < want {
+			notReady = append(notReady, fmt.Sprintf("%s %d/%d", item.Metadata.Name, item.Status.ReadyReplicas, want))
+		}
+	}
+
+	switch {
+	case total == 0:
+		return componentStatus{Status: "Missing", Detail: "not installed"}
+	case len(notReady) > 0:
+		return componentStatus{Status: "Degraded", Detail: "not ready: " + strings.Join(notReady, ", ")}
+	default:
+		return componentStatus{Status: "Healthy", Detail: fmt.Sprintf("%d workload(s) ready", total)}
 	}
-	fmt.Println(string(output))
-	return nil
 }
 
-func showPodsStatus() error {
-	fmt.Println("")
-	fmt.Println("📊 Pods Status:")
-	cmd := exec.Command("kubectl", "get", "pods", "--all-namespaces")
-	output, err := runCommand(cmd, "kubectl get pods --all-namespaces")
-	if err != nil {
-		return fmt.Errorf("failed to get pods: %w", err)
+// collectComponentStatus checks ArgoCD, ChartMuseum, the git server and the registry
+func collectComponentStatus(config *Config) []componentStatus {
+	argoCD := workloadStatus(argocdNamespace, "")
+	argoCD.Name = "ArgoCD (" + config.ArgoCDInstall + ")"
+
+	chartMuseum := workloadStatus("chartmuseum", "chartmuseum")
+	chartMuseum.Name = "ChartMuseum"
+
+	gitServer := componentStatus{Status: "Healthy", Detail: "git-server serve is running"}
+	if config.GitServerBackend == gitBackendHost {
+		if err := waitForHTTP(fmt.Sprintf("http://localhost:%s/", config.GitServerPort), time.Second); err != nil {
+			gitServer = componentStatus{Status: "Down", Detail: "start it with 'gitops git-server serve'"}
+		}
+	} else {
+		gitServer = workloadStatus("git-server", "git-server")
 	}
-	fmt.Println(string(output))
-	return nil
+	gitServer.Name = "Git server (" + config.GitServerBackend + ")"
+
+	registry := componentStatus{Name: "Registry", Status: "Healthy"}
+	if repositories, err := newRegistryClient(config).catalog(); err != nil {
+		registry.Status, registry.Detail = "Down", fmt.Sprintf("%s:%s not reachable", config.RegistryName, config.RegistryPort)
+	} else {
+		registry.Detail = fmt.Sprintf("%s:%s, %d repositories", config.RegistryName, config.RegistryPort, len(repositories))
+	}
+
+	return []componentStatus{argoCD, chartMuseum, gitServer, registry}
 }
 
-func showArgoCDApplications() error {
-	fmt.Println("")
-	fmt.Println("📊 ArgoCD Applications:")
-	cmd := exec.Command("kubectl", "get", "applications", "-n", "argocd")
-	output, err := runCommand(cmd, "kubectl get applications -n argocd")
+// collectApplicationStatus returns the state of every Application, with the commit
+// message of the deployed revision when it comes from the local git server
+func collectApplicationStatus(config *Config, targetDir string) ([]applicationStatus, error) {
+	apps, err := listArgoCDApplications()
 	if err != nil {
-		fmt.Println("No ArgoCD applications found")
-		return nil
+		return nil, err
 	}
-	fmt.Println(string(output))
-	return nil
+
+	// Commit messages are best effort and read in place, without connecting to the
+	// git server, so refreshing status stays cheap
+	var backend gitBackend
+	if len(apps) > 0 {
+		if b, err := newGitBackend(config, targetDir); err == nil {
+			backend = b
+		}
+	}
+
+	var statuses []applicationStatus
+	for _, app := range apps {
+		status := newApplicationStatus(app)
+		if backend != nil && status.Revision != "" {
+			if name, ok := gitRepoName(config, app.Spec.Source.RepoURL); ok {
+				status.CommitMessage, _ = backend.CommitMessage(name, status.Revision)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	sortApplicationStatus(statuses)
+	return statuses, nil
 }
 
-func showExampleAppStatus() error {
-	fmt.Println("")
-	fmt.Println("📊 Example App Status:")
-	cmd := exec.Command("kubectl", "get", "pods,svc,ingress", "-l", "app=example-app")
-	output, err := runCommand(cmd, "kubectl get pods,svc,ingress -l app=example-app")
-	if err != nil {
-		fmt.Println("Example app not deployed")
-		return nil
+// newApplicationStatus summarizes an Application without its commit message
+func newApplicationStatus(app argoCDApplication) applicationStatus {
+	status := applicationStatus{
+		Name:           app.Metadata.Name,
+		Project:        app.Spec.Project,
+		ApplicationSet: app.applicationSet(),
+		Sync:           app.Status.Sync.Status,
+		Health:         app.Status.Health.Status,
+		Revision:       app.Status.Sync.Revision,
+		Problems:       app.problems(),
+	}
+
+	if operation := app.Status.OperationState; operation.Phase != "" {
+		status.LastOperation = operation.Phase
+		if operation.FinishedAt != "" {
+			status.LastOperation += " at " + operation.FinishedAt
+		}
+	}
+
+	for _, resource := range app.Status.Resources {
+		status.Resources = append(status.Resources, resourceStatus{
+			Kind:      strings.TrimPrefix(resource.Group+"/"+resource.Kind, "/"),
+			Namespace: resource.Namespace,
+			Name:      resource.Name,
+			Sync:      resource.Status,
+			Health:    resource.Health.Status,
+			Message:   resource.Health.Message,
+		})
+	}
+	return status
+}
+
+// sortApplicationStatus groups generated Applications under their ApplicationSet,
+// standalone ones last
+func sortApplicationStatus(statuses []applicationStatus) {
+	sort.SliceStable(statuses, func(i, j int) bool {
+		a, b := statuses[i].ApplicationSet, statuses[j].ApplicationSet
+		if a == "" || b == "" {
+			return a != "" && b == ""
+		}
+		return a < b
+	})
+}
+
+func printComponentStatus(out io.Writer, components []componentStatus) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COMPONENT\tSTATUS\tDETAILS")
+	for _, c := range components {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", c.Name, c.Status, c.Detail)
+	}
+	return w.Flush()
+}
+
+// shortRevision abbreviates a commit hash for display
+func shortRevision(revision string) string {
+	if len(revision) > 7 {
+		return revision[:7]
+	}
+	return revision
+}
+
+// printApplicationStatus prints one row per Application, then the resource tree of
+// unhealthy Applications and the problems ArgoCD reported
+func printApplicationStatus(out io.Writer, apps []applicationStatus) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPROJECT\tSYNC\tHEALTH\tREVISION\tCOMMIT\tLAST OPERATION")
+	for _, app := range apps {
+		name := app.Name
+		if app.ApplicationSet != "" {
+			name = app.ApplicationSet + "/" + app.Name
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", name, app.Project, app.Sync, app.Health,
+			shortRevision(app.Revision), app.CommitMessage, app.LastOperation)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, app := range apps {
+		if app.Health == "Healthy" && len(app.Problems) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(out, "\n🔍 %s (%s):\n", app.Name, app.Health)
+		for i, resource := range app.Resources {
+			branch := "├──"
+			if i == len(app.Resources)-1 {
+				branch = "└──"
+			}
+			line := fmt.Sprintf("  %s %s %s", branch, resource.Kind, resource.Name)
+			if resource.Namespace != "" {
+				line += " -n " + resource.Namespace
+			}
+			line += fmt.Sprintf(" [%s, %s]", resource.Sync, resource.Health)
+			if resource.Message != "" {
+				line += " " + resource.Message
+			}
+			fmt.Fprintln(out, line)
+		}
+		for _, problem := range app.Problems {
+			fmt.Fprintf(out, "  ⚠️  %s\n", problem)
+		}
 	}
-	fmt.Println(string(output))
 	return nil
 }
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the CLI version and, with --tools, the external tool compatibility",
+	Args:  cobra.NoArgs,
+	RunE:  runVersion,
+}
+
+var versionTools bool
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionTools, "tools", false, "Check docker, k3d and kubectl against the supported version ranges")
+}
+
+// Status of an external tool against its supported version range
+const (
+	toolOK          = "ok"
+	toolUnsupported = "unsupported"
+	toolMissing     = "missing"
+	toolUnavailable = "unavailable"
+	toolUnknown     = "unknown"
+)
+
+// toolRequirement is the supported version range of an external tool
+type toolRequirement struct {
+	Name string
+	// Args run the tool so that it prints its version
+	Args  []string
+	Range string
+	// Install is where to get the tool; Unavailable explains a failing version command
+	Install     string
+	Unavailable string
+}
+
+// toolRequirements are the external tools the CLI drives and the versions it supports
+var toolRequirements = []toolRequirement{
+	{
+		Name:        "docker",
+		Args:        []string{"docker", "version", "--format", "{{.Server.Version}}"},
+		Range:       ">=20.10.0",
+		Install:     "https://docs.docker.com/get-docker/",
+		Unavailable: "the Docker daemon is not running; start Docker Desktop or the docker service",
+	},
+	{
+		Name:        "k3d",
+		Args:        []string{"k3d", "version"},
+		Range:       ">=5.4.0 <6.0.0",
+		Install:     "https://k3d.io/#installation",
+		Unavailable: "'k3d version' failed",
+	},
+	{
+		Name:        "kubectl",
+		Args:        []string{"kubectl", "version", "--client"},
+		Range:       ">=1.25.0 <2.0.0",
+		Install:     "https://kubernetes.io/docs/tasks/tools/",
+		Unavailable: "'kubectl version --client' failed",
+	},
+}
+
+// toolVersion is an installed tool checked against its requirement
+type toolVersion struct {
+	Name     string `json:"name"`
+	Found    string `json:"found"`
+	Required string `json:"required"`
+	Status   string `json:"status"`
+}
+
+// detectToolVersion runs the tool's version command and checks the result
+func detectToolVersion(req toolRequirement) toolVersion {
+	tool := toolVersion{Name: req.Name, Required: req.Range}
+	if _, err := exec.LookPath(req.Args[0]); err != nil {
+		tool.Status = toolMissing
+		return tool
+	}
+
+	output, err := exec.Command(req.Args[0], req.Args[1:]...).Output()
+	if err != nil {
+		tool.Status = toolUnavailable
+		return tool
+	}
+	found, ok := findSemver(string(output))
+	if !ok {
+		tool.Status = toolUnknown
+		return tool
+	}
+
+	// Distribution suffixes such as "-k3s1" or "-desktop.1" are not prereleases
+	found.Prerelease = ""
+	tool.Found = found.String()
+	constraint, err := parseSemverConstraint(req.Range)
+	if err != nil || !constraint.check(found) {
+		tool.Status = toolUnsupported
+		return tool
+	}
+	tool.Status = toolOK
+	return tool
+}
+
+// toolCheck returns a doctor check of a tool's presence and version
+func toolCheck(name string) func(config *Config) diagnosis {
+	return func(config *Config) diagnosis {
+		var req toolRequirement
+		for _, r := range toolRequirements {
+			if r.Name == name {
+				req = r
+			}
+		}
+
+		tool := detectToolVersion(req)
+		switch tool.Status {
+		case toolOK:
+			return diagnosis{Result: checkPass, Detail: fmt.Sprintf("%s %s", tool.Name, tool.Found)}
+		case toolMissing:
+			return diagnosis{Result: checkFail, Detail: tool.Name + " is not installed", Fix: "install " + tool.Name + ": " + req.Install}
+		case toolUnavailable:
+			return diagnosis{Result: checkFail, Detail: req.Unavailable, Fix: "check the " + tool.Name + " installation: " + req.Install}
+		case toolUnknown:
+			return diagnosis{Result: checkWarn, Detail: "could not read the " + tool.Name + " version",
+				Fix: fmt.Sprintf("make sure %s prints a version; supported: %s", strings.Join(req.Args, " "), req.Range)}
+		default:
+			return diagnosis{Result: checkFail, Detail: fmt.Sprintf("%s %s is not supported, requires %s", tool.Name, tool.Found, tool.Required),
+				Fix: "install a supported " + tool.Name + ": " + req.Install}
+		}
+	}
+}
+
+// versionReport is the document version emits with --output json|yaml
+type versionReport struct {
+	Version   string        `json:"version"`
+	Commit    string        `json:"commit"`
+	BuildTime string        `json:"buildTime"`
+	Platform  string        `json:"platform"`
+	Tools     []toolVersion `json:"tools,omitempty"`
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	report := versionReport{
+		Version:   version,
+		Commit:    commit,
+		BuildTime: buildTime,
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if versionTools {
+		for _, req := range toolRequirements {
+			report.Tools = append(report.Tools, detectToolVersion(req))
+		}
+	}
+
+	if structuredOutputEnabled() {
+		return writeOutput(cmd.OutOrStdout(), report)
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "gitops %s (commit: %s, built: %s, %s)\n", report.Version, report.Commit, report.BuildTime, report.Platform)
+	if !versionTools {
+		return nil
+	}
+
+	// A Markdown table, ready to paste into bug reports
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "| Tool | Found | Required | Status |")
+	fmt.Fprintln(out, "|------|-------|----------|--------|")
+	for _, tool := range report.Tools {
+		found := tool.Found
+		if found == "" {
+			found = "-"
+		}
+		icon := "✅"
+		if tool.Status != toolOK {
+			icon = "❌"
+		}
+		fmt.Fprintf(out, "| %s | %s | %s | %s %s |\n", tool.Name, found, tool.Required, icon, tool.Status)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade ArgoCD and components to the configured versions",
+	Long: `Compares the component images running in the cluster with the versions configured in
+.gitops-config.yaml (or pinned by this CLI release) and upgrades them in place without recreating the cluster.`,
+	RunE: runUpgrade,
+}
+
+var (
+	upgradeTargetDir string
+	upgradeCheck     bool
+)
+
+func init() {
+	upgradeCmd.Flags().StringVar(&upgradeTargetDir, "target-dir", ".", "Target directory containing .gitops-config.yaml")
+	upgradeCmd.Flags().BoolVar(&upgradeCheck, "check", false, "Only show current and target versions")
+}
+
+func runUpgrade(cmd *cobra.Command, args []string) error {
+	config, err := readConfig(upgradeTargetDir)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := setKubeconfig(config.ClusterName); err != nil {
+		return fmt.Errorf("failed to set kubeconfig: %w", err)
+	}
+
+	// Compare running images with the configured targets
+	outdated := map[string]bool{}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COMPONENT\tCURRENT\tTARGET\tSTATUS")
+	for _, c := range components(config) {
+		current, err := deployedImage(c)
+		if err != nil || current == "" {
+			current = "not installed"
+		}
+
+		target := c.Image
+		if config.PinDigests {
+			digest, err := resolveRemoteDigest(c.Image)
+			if err != nil {
+				return fmt.Errorf("failed to resolve digest of %s: %w", c.Image, err)
+			}
+			target += "@" + digest
+		}
+
+		status := "up to date"
+		if current != target {
+			status = "upgrade"
+			outdated[c.Name] = true
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Name, current, target, status)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(outdated) == 0 {
+		fmt.Fprintln(progress, "✅ All components are up to date")
+		return nil
+	}
+	if upgradeCheck {
+		return nil
+	}
+
+	manifests, err := renderSetupManifests(config, config.PinDigests)
+	if err != nil {
+		return fmt.Errorf("failed to prepare component manifests: %w", err)
+	}
+
+	if outdated["ArgoCD"] {
+		if err := upgradeArgoCD(manifests.ArgoCD); err != nil {
+			return fmt.Errorf("failed to upgrade ArgoCD: %w", err)
+		}
+		if err := applyArgoCDProfile(config); err != nil {
+			return fmt.Errorf("failed to apply ArgoCD profile: %w", err)
+		}
+	}
+	if outdated["ChartMuseum"] {
+		if err := installChartMuseum(manifests.ChartMuseum); err != nil {
+			return fmt.Errorf("failed to upgrade ChartMuseum: %w", err)
+		}
+	}
+	if outdated["Git server"] {
+		backend, err := newGitBackend(config, upgradeTargetDir)
+		if err != nil {
+			return err
+		}
+		if err := backend.Install(manifests.GitServer); err != nil {
+			return fmt.Errorf("failed to upgrade Git server: %w", err)
+		}
+	}
+
+	fmt.Fprintln(progress, "✅ Upgrade completed")
+	return nil
+}
+
+// upgradeArgoCD applies a new ArgoCD manifest and waits for every rollout to finish
+func upgradeArgoCD(manifest string) error {
+	fmt.Fprintln(progress, "🚀 Upgrading ArgoCD...")
+
+	cmd := exec.Command("kubectl", "apply", "-n", "argocd", "-f", "-")
+	cmd.Stdin = strings.NewReader(manifest)
+	if _, err := runCommand(cmd, "kubectl apply ArgoCD"); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(progress, "⏳ Waiting for ArgoCD rollout...")
+	if err := waitForArgoCDRollout(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(progress, "✅ ArgoCD upgraded successfully")
+	return nil
+}
@@ -21,47 +21,146 @@ func setKubeconfig(clusterName string) error {
 	return nil
 }
 
-// Config holds the GitOps configuration
-type Config struct {
-	ClusterName     string
-	RegistryName    string
-	RegistryPort    string
-	ArgoCDPort      string
-	ChartMuseumPort string
-	GitServerPort   string
-}
-
-// readConfig reads the GitOps configuration from the specified directory
-func readConfig(configDir string) (*Config, error) {
-	configPath := filepath.Join(configDir, ".gitops-config.yaml")
+// gitopsHomeDir returns the per-user directory for state shared across projects
+func gitopsHomeDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user config directory: %w", err)
+	}
 
-	// Check if config file exists
-	if _, err := os.Stat(configPath); err != nil {
-		// If no config file, use default values
-		return &Config{
-			ClusterName:     "devcluster",
-			RegistryName:    "myregistry.localhost",
-			RegistryPort:    "5001",
-			ArgoCDPort:      "8083",
-			ChartMuseumPort: "8084",
-			GitServerPort:   "8085",
-		}, nil
+	dir := filepath.Join(configDir, "local-gitops")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
 	}
+	return dir, nil
+}
 
-	// Read config file
-	content, err := os.ReadFile(configPath)
+// projectStateDir returns the private state directory of the project in targetDir
+func projectStateDir(targetDir string) (string, error) {
+	absDir, err := filepath.Abs(targetDir)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	// Parse config file
-	config := &Config{
+	dir := filepath.Join(absDir, ".gitops")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// Config holds the GitOps configuration
+type Config struct {
+	ClusterName     string        `json:"cluster_name"`
+	RegistryName    string        `json:"registry_name"`
+	RegistryPort    string        `json:"registry_port"`
+	ArgoCDPort      string        `json:"argocd_port"`
+	ChartMuseumPort string        `json:"chartmuseum_port"`
+	GitServerPort   string        `json:"git_server_port"`
+	ImagePolicies   []ImagePolicy `json:"image_policy"`
+	// Mirrors are local repositories pushed to the git server on every deploy
+	Mirrors []RepoMirror `json:"mirror"`
+	// AppProjects are created at deploy; ApplicationProject is the project deployed
+	// Applications are bound to instead of "default"
+	AppProjects        []AppProject `json:"app_project"`
+	ApplicationProject string       `json:"application_project"`
+
+	// PullThroughCache mirrors upstream registries through local cache registries
+	PullThroughCache bool   `json:"pull_through_cache"`
+	CachePort        string `json:"cache_port"`
+
+	// Component versions; PinDigests resolves their images to digests at setup
+	ArgoCDVersion      string `json:"argocd_version"`
+	ChartMuseumVersion string `json:"chartmuseum_version"`
+	GitServerVersion   string `json:"git_server_version"`
+	GitImageVersion    string `json:"git_image_version"`
+	PinDigests         bool   `json:"pin_digests"`
+
+	// GitServerBackend selects the git server: basic, gitea or host
+	GitServerBackend string `json:"git_server_backend"`
+	GiteaVersion     string `json:"gitea_version"`
+	// GitServerAuth requires HTTP basic auth on the git server and registers the
+	// credentials with ArgoCD
+	GitServerAuth bool `json:"git_server_auth"`
+
+	// ApplicationSet makes deploy generate one Application per directory matching
+	// ApplicationSetDirectories (comma separated globs) instead of applying bootstrap.yaml
+	ApplicationSet            bool   `json:"applicationset"`
+	ApplicationSetDirectories string `json:"applicationset_directories"`
+
+	// ArgoCDInstall selects the install variant: full, core or namespace.
+	// ArgoCDNamespaces lists the namespaces a namespace-scoped ArgoCD may deploy to.
+	ArgoCDInstall    string `json:"argocd_install"`
+	ArgoCDNamespaces string `json:"argocd_namespaces"`
+
+	// ArgoCDProfile tunes ArgoCD after install: local (fast feedback) or default (stock)
+	ArgoCDProfile               string `json:"argocd_profile"`
+	ArgoCDReconciliationTimeout string `json:"argocd_reconciliation_timeout"`
+	ArgoCDRepoCacheExpiration   string `json:"argocd_repo_cache_expiration"`
+	ArgoCDInsecure              bool   `json:"argocd_insecure"`
+	ArgoCDCPURequest            string `json:"argocd_cpu_request"`
+	ArgoCDMemoryRequest         string `json:"argocd_memory_request"`
+	ArgoCDResourceExclusions    string `json:"argocd_resource_exclusions"`
+
+	// SecretStore selects where generated credentials are kept: auto, keyring or file
+	SecretStore string `json:"secret_store"`
+
+	// Cluster shape, rendered into the k3d config file setup creates the cluster from.
+	// KubernetesVersion selects the k3s image when ClusterImage is not set.
+	ClusterServers    int            `json:"cluster_servers"`
+	ClusterAgents     int            `json:"cluster_agents"`
+	ClusterImage      string         `json:"cluster_image,omitempty"`
+	KubernetesVersion string         `json:"kubernetes_version,omitempty"`
+	ClusterAPIPort    string         `json:"cluster_api_port,omitempty"`
+	ClusterPorts      []NodeFiltered `json:"cluster_port"`
+	ClusterVolumes    []NodeFiltered `json:"cluster_volume"`
+	ClusterNodeLabels []NodeFiltered `json:"cluster_node_label"`
+	ClusterNodeTaints []NodeFiltered `json:"cluster_node_taint"`
+	ClusterK3sArgs    []NodeFiltered `json:"cluster_k3s_arg"`
+}
+
+// defaultConfig returns the configuration used for keys the config file does not set
+func defaultConfig() *Config {
+	return &Config{
 		ClusterName:     "devcluster",
 		RegistryName:    "myregistry.localhost",
 		RegistryPort:    "5001",
 		ArgoCDPort:      "8083",
 		ChartMuseumPort: "8084",
 		GitServerPort:   "8085",
+		CachePort:       "5100",
+
+		ArgoCDVersion:               defaultArgoCDVersion,
+		ChartMuseumVersion:          defaultChartMuseumVersion,
+		GitServerVersion:            defaultGitServerVersion,
+		GitImageVersion:             defaultGitImageVersion,
+		PinDigests:                  true,
+		SecretStore:                 "auto",
+		GitServerBackend:            gitBackendBasic,
+		GiteaVersion:                defaultGiteaVersion,
+		ApplicationSetDirectories:   defaultApplicationSetDirectories,
+		ArgoCDInstall:               argoCDInstallFull,
+		ArgoCDNamespaces:            defaultArgoCDNamespaces,
+		ArgoCDProfile:               argoCDProfileLocal,
+		ArgoCDReconciliationTimeout: defaultArgoCDReconciliationTimeout,
+		ArgoCDRepoCacheExpiration:   defaultArgoCDRepoCacheExpiration,
+		ArgoCDInsecure:              true,
+		ArgoCDCPURequest:            defaultArgoCDCPURequest,
+		ArgoCDMemoryRequest:         defaultArgoCDMemoryRequest,
+		ArgoCDResourceExclusions:    defaultArgoCDResourceExclusions,
+		ClusterServers:              1,
+	}
+}
+
+// readConfig reads the GitOps configuration from the specified directory
+func readConfig(configDir string) (*Config, error) {
+	configPath := filepath.Join(configDir, ".gitops-config.yaml")
+	config := defaultConfig()
+
+	// Without a config file every key keeps its default
+	content, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
 	}
 
 	lines := strings.Split(string(content), "\n")
@@ -71,8 +170,8 @@ func readConfig(configDir string) (*Config, error) {
 			continue
 		}
 
-		parts := strings.Split(line, ":")
-		if len(parts) >= 2 {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) == 2 {
 			key := strings.TrimSpace(parts[0])
 			value := strings.TrimSpace(parts[1])
 
@@ -89,17 +188,106 @@ func readConfig(configDir string) (*Config, error) {
 				config.ChartMuseumPort = value
 			case "git_server_port":
 				config.GitServerPort = value
+			case "image_policy":
+				policy, err := parseImagePolicy(value)
+				if err != nil {
+					return nil, err
+				}
+				config.ImagePolicies = append(config.ImagePolicies, *policy)
+			case "app_project":
+				project, err := parseAppProject(value)
+				if err != nil {
+					return nil, err
+				}
+				config.AppProjects = append(config.AppProjects, *project)
+			case "application_project":
+				config.ApplicationProject = value
+			case "mirror":
+				mirror, err := parseRepoMirror(value)
+				if err != nil {
+					return nil, err
+				}
+				config.Mirrors = append(config.Mirrors, *mirror)
+			case "pull_through_cache":
+				config.PullThroughCache = value == "true"
+			case "cache_port":
+				config.CachePort = value
+			case "argocd_version":
+				config.ArgoCDVersion = value
+			case "chartmuseum_version":
+				config.ChartMuseumVersion = value
+			case "git_server_version":
+				config.GitServerVersion = value
+			case "git_image_version":
+				config.GitImageVersion = value
+			case "pin_digests":
+				config.PinDigests = value != "false"
+			case "secret_store":
+				config.SecretStore = value
+			case "git_server_backend":
+				config.GitServerBackend = value
+			case "gitea_version":
+				config.GiteaVersion = value
+			case "git_server_auth":
+				config.GitServerAuth = value == "true"
+			case "applicationset":
+				config.ApplicationSet = value == "true"
+			case "applicationset_directories":
+				config.ApplicationSetDirectories = value
+			case "argocd_install":
+				config.ArgoCDInstall = value
+			case "argocd_namespaces":
+				config.ArgoCDNamespaces = value
+			case "argocd_profile":
+				config.ArgoCDProfile = value
+			case "argocd_reconciliation_timeout":
+				config.ArgoCDReconciliationTimeout = value
+			case "argocd_repo_cache_expiration":
+				config.ArgoCDRepoCacheExpiration = value
+			case "argocd_insecure":
+				config.ArgoCDInsecure = value != "false"
+			case "argocd_cpu_request":
+				config.ArgoCDCPURequest = value
+			case "argocd_memory_request":
+				config.ArgoCDMemoryRequest = value
+			case "argocd_resource_exclusions":
+				config.ArgoCDResourceExclusions = value
+			case "cluster_servers":
+				if config.ClusterServers, err = parseClusterNodeCount(key, value, 1); err != nil {
+					return nil, err
+				}
+			case "cluster_agents":
+				if config.ClusterAgents, err = parseClusterNodeCount(key, value, 0); err != nil {
+					return nil, err
+				}
+			case "cluster_image":
+				config.ClusterImage = value
+			case "kubernetes_version":
+				if config.KubernetesVersion, err = parseKubernetesVersion(value); err != nil {
+					return nil, err
+				}
+			case "cluster_api_port":
+				config.ClusterAPIPort = value
+			case "cluster_port", "cluster_volume", "cluster_node_label", "cluster_node_taint", "cluster_k3s_arg":
+				if err := config.addClusterOption(key, value); err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
 
+	// The load balancer ports are replaced, not extended, by cluster_port entries
+	if len(config.ClusterPorts) == 0 {
+		config.ClusterPorts = defaultClusterPorts()
+	}
+
 	return config, nil
 }
 
 // runCommand executes a command and returns its output with enhanced error handling
 func runCommand(cmd *exec.Cmd, description string) ([]byte, error) {
 	if verbose {
-		fmt.Printf("🔧 Running: %s %s\n", cmd.Path, strings.Join(cmd.Args[1:], " "))
+		fmt.Fprintf(progress, "🔧 Running: %s %s\n", cmd.Path, strings.Join(cmd.Args[1:], " "))
 	}
 
 	output, err := cmd.Output()
@@ -124,7 +312,7 @@ func runCommand(cmd *exec.Cmd, description string) ([]byte, error) {
 	}
 
 	if verbose && len(output) > 0 {
-		fmt.Printf("📤 Output: %s\n", string(output))
+		fmt.Fprintf(progress, "📤 Output: %s\n", string(output))
 	}
 
 	return output, nil
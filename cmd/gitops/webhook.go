@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// argoCDWebhookURL is the in-cluster ArgoCD webhook endpoint git servers notify on push
+const argoCDWebhookURL = "http://argocd-server.argocd.svc.cluster.local/api/webhook"
+
+// gitPushEvent is the subset of a GitHub push event ArgoCD uses to find the
+// Applications to refresh
+type gitPushEvent struct {
+	Ref        string `json:"ref"`
+	Before     string `json:"before"`
+	After      string `json:"after"`
+	Repository struct {
+		HTMLURL       string `json:"html_url"`
+		DefaultBranch string `json:"default_branch"`
+	} `json:"repository"`
+	Commits []struct{} `json:"commits"`
+}
+
+// backendSendsWebhooks reports whether the git server notifies ArgoCD on every push
+// by itself. Gitea sends webhooks when argocd-server is installed and the host server
+// notifies after each receive-pack.
+func backendSendsWebhooks(config *Config) bool {
+	if config.GitServerBackend == gitBackendGitea {
+		return argoCDHasServer(config)
+	}
+	return config.GitServerBackend == gitBackendHost
+}
+
+// freeLocalPort returns a TCP port on localhost that is currently unused
+func freeLocalPort() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+	return fmt.Sprint(listener.Addr().(*net.TCPAddr).Port), nil
+}
+
+// postArgoCDWebhook sends a push event for a branch of a repository to argocd-server,
+// and to the ApplicationSet controller when deploy generates an ApplicationSet, through
+// temporary port forwards. Without argocd-server the Applications of the repository
+// are refreshed directly.
+func postArgoCDWebhook(config *Config, repository, branch string) error {
+	event := gitPushEvent{Ref: "refs/heads/" + branch, Commits: []struct{}{}}
+	event.Repository.HTMLURL = strings.TrimSuffix(gitRepoURL(config, repository), ".git")
+	event.Repository.DefaultBranch = "master"
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if config.ApplicationSet {
+		if err := postWebhook("svc/argocd-applicationset-controller", "7000", "http", payload); err != nil {
+			return fmt.Errorf("ApplicationSet webhook: %w", err)
+		}
+	}
+	if !argoCDHasServer(config) {
+		return refreshArgoCDApplications(config, repository)
+	}
+	return postWebhook("svc/argocd-server", "443", argoCDServerScheme(config), payload)
+}
+
+// postWebhook posts a GitHub push event to /api/webhook of an ArgoCD service
+func postWebhook(service, remotePort, scheme string, payload []byte) error {
+	port, err := freeLocalPort()
+	if err != nil {
+		return err
+	}
+	forward := exec.Command("kubectl", "port-forward", "-n", argocdNamespace, service, port+":"+remotePort)
+	if err := forward.Start(); err != nil {
+		return fmt.Errorf("failed to start port forward to %s: %w", service, err)
+	}
+	defer forward.Process.Kill()
+
+	// argocd-server uses a self-signed certificate unless it runs insecure
+	client := &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	url := fmt.Sprintf("%s://localhost:%s/api/webhook", scheme, port)
+	if err := waitForHTTPClient(client, url, 10*time.Second); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", "push")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s webhook returned %s", service, resp.Status)
+	}
+	return nil
+}
+
+// notifyArgoCD refreshes the Applications of a repository after the CLI pushed to it,
+// unless the git server already sent a webhook. Failures only delay the refresh until
+// the next ArgoCD poll, so they are reported as warnings.
+func notifyArgoCD(config *Config, repository, branch string) {
+	if backendSendsWebhooks(config) {
+		return
+	}
+	if err := postArgoCDWebhook(config, repository, branch); err != nil {
+		fmt.Fprintf(progress, "⚠️  Failed to notify ArgoCD about the push to %s: %v\n", repository, err)
+		return
+	}
+	if verbose {
+		fmt.Fprintf(progress, "🔔 Notified ArgoCD about the push to %s\n", repository)
+	}
+}